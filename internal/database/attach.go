@@ -0,0 +1,161 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// a database on the connection, main, temp or an attached file
+type Schema struct {
+	Name   string
+	File   string   // empty for temp and in-memory databases
+	Tables []string // names as the other methods take them, qualified outside main
+}
+
+// returns the databases on the connection in attach order with their tables
+func (m *Manager) ListSchemas() ([]Schema, error) {
+	rows, err := m.conn().Query(`SELECT name, file FROM pragma_database_list ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list databases: %w", err)
+	}
+
+	var schemas []Schema
+	for rows.Next() {
+		var s Schema
+		if err := rows.Scan(&s.Name, &s.File); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Failed to scan database: %w", err)
+		}
+		schemas = append(schemas, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating databases: %w", err)
+	}
+
+	// the tables are read once the database rows are closed, there is one connection
+	for i := range schemas {
+		tables, err := m.schemaTables(schemas[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i].Tables = tables
+	}
+	return schemas, nil
+}
+
+// table names of a schema, qualified unless it is main
+func (m *Manager) schemaTables(schema string) ([]string, error) {
+	rows, err := m.conn().Query(`SELECT name FROM ` + quoteIdentifier(schema) + `.sqlite_master
+	WHERE type ='table' AND name NOT LIKE 'sqlite_%'
+	ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get table names of %s: %w", schema, err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Failed to scan table: %w", err)
+		}
+		if schema != "main" {
+			name = schema + "." + name
+		}
+		tables = append(tables, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating tables: %w", err)
+	}
+	return tables, nil
+}
+
+// attaches the database file at path under schema, named after the file when
+// schema is empty. the file is opened read-only when the main database is.
+// returns the schema name
+func (m *Manager) Attach(path, schema string) (string, error) {
+	opts := Options{ReadOnly: m.readOnly, Create: m.create}
+	if err := checkPath(path, opts); err != nil {
+		return "", err
+	}
+
+	if m.inTx() {
+		return "", fmt.Errorf("Commit or roll back the open transaction before attaching a database")
+	}
+
+	schemas, err := m.ListSchemas()
+	if err != nil {
+		return "", err
+	}
+	taken := make(map[string]bool, len(schemas))
+	for _, s := range schemas {
+		taken[strings.ToLower(s.Name)] = true
+	}
+
+	if schema == "" {
+		schema = schemaName(path, taken)
+	}
+	if strings.Contains(schema, ".") {
+		return "", fmt.Errorf("Database name %q cannot contain a dot", schema)
+	}
+	if taken[strings.ToLower(schema)] {
+		return "", fmt.Errorf("Database name %s is already in use", schema)
+	}
+
+	if _, err := m.db.Exec(`ATTACH DATABASE ? AS ?`, dsn(path, opts), schema); err != nil {
+		return "", fmt.Errorf("Failed to attach %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// detaches a database attached with Attach
+func (m *Manager) Detach(schema string) error {
+	if strings.EqualFold(schema, "main") || strings.EqualFold(schema, "temp") {
+		return fmt.Errorf("Cannot detach the %s database", schema)
+	}
+
+	if m.inTx() {
+		return fmt.Errorf("Commit or roll back the open transaction before detaching a database")
+	}
+
+	if _, err := m.db.Exec(`DETACH DATABASE ?`, schema); err != nil {
+		return fmt.Errorf("Failed to detach %s: %w", schema, err)
+	}
+	return nil
+}
+
+// true while transaction mode holds uncommitted writes, ATTACH fails inside one
+func (m *Manager) inTx() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tx != nil
+}
+
+// a schema name from the file name, ex. backups/prod-2024.db is prod_2024,
+// with a number added when it is taken
+func schemaName(path string, taken map[string]bool) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	var b strings.Builder
+	for _, r := range base {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	name := b.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "db" + name
+	}
+
+	unique := name
+	for i := 2; taken[strings.ToLower(unique)]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	return unique
+}
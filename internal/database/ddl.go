@@ -0,0 +1,166 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the CREATE statement of a table and what PRAGMA table_list knows about it
+type TableDefinition struct {
+	SQL          string
+	Columns      []Column // every column, hidden ones too
+	Type         string   // table, view, virtual or shadow
+	WithoutRowid bool
+	Strict       bool
+}
+
+// Returns the CREATE statement, columns and flags of a table
+func (m *Manager) GetTableDefinition(tableName string) (TableDefinition, error) {
+	var def TableDefinition
+
+	cols, err := m.tableColumns(tableName)
+	if err != nil {
+		return def, err
+	}
+	def.Columns = cols
+
+	ref := m.resolve(tableName)
+	var createSQL sql.NullString
+	err = m.conn().QueryRow(`SELECT sql FROM `+ref.master()+` WHERE name = ? COLLATE NOCASE`, ref.name).Scan(&createSQL)
+	if err != nil && err != sql.ErrNoRows {
+		return def, fmt.Errorf("Failed to get CREATE statement: %w", err)
+	}
+	def.SQL = createSQL.String
+
+	var wr, strict int
+	err = m.conn().QueryRow(`SELECT type, wr, strict FROM pragma_table_list(?) WHERE schema = ?`, ref.name, ref.schema).
+		Scan(&def.Type, &wr, &strict)
+	if err != nil && err != sql.ErrNoRows {
+		return def, fmt.Errorf("Failed to get table flags: %w", err)
+	}
+	def.WithoutRowid = wr == 1
+	def.Strict = strict == 1
+
+	return def, nil
+}
+
+// lower case names of the columns covered by a unique index on that column alone
+func (m *Manager) uniqueColumns(ref tableRef) (map[string]bool, error) {
+	rows, err := m.conn().Query(
+		`SELECT min(i.name) FROM pragma_index_list(?1, ?2) l, pragma_index_info(l.name, ?2) i
+		WHERE l."unique" = 1 AND l.partial = 0 AND l.origin != 'pk'
+		GROUP BY l.name HAVING count(*) = 1`,
+		ref.name, ref.schema,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get unique columns: %w", err)
+	}
+	defer rows.Close()
+
+	unique := make(map[string]bool)
+	for rows.Next() {
+		var name sql.NullString // NULL for an expression
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Failed to scan unique column: %w", err)
+		}
+		if name.Valid {
+			unique[strings.ToLower(name.String)] = true
+		}
+	}
+	return unique, rows.Err()
+}
+
+// words starting a table constraint rather than a column definition
+var tableConstraints = map[string]bool{"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "CHECK": true, "FOREIGN": true}
+
+// splits the body of a CREATE TABLE statement into column definitions by lower case column name
+func columnDefs(createSQL string) map[string]string {
+	defs := make(map[string]string)
+
+	start := strings.Index(createSQL, "(")
+	if start < 0 {
+		return defs
+	}
+
+	for _, def := range splitTopLevel(createSQL[start+1:]) {
+		name, rest, quoted := splitName(def)
+		if name == "" || !quoted && tableConstraints[strings.ToUpper(name)] {
+			continue
+		}
+		defs[strings.ToLower(name)] = rest
+	}
+	return defs
+}
+
+// splits on commas outside of parentheses and quotes, stopping at the closing parenthesis
+func splitTopLevel(body string) []string {
+	var parts []string
+	var quote byte
+	depth := 0
+	last := 0
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')' && depth == 0:
+			return append(parts, strings.TrimSpace(body[last:i]))
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(body[last:i]))
+			last = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(body[last:]))
+}
+
+// splits a column definition into its unquoted name and the rest
+func splitName(def string) (name, rest string, quoted bool) {
+	if def == "" {
+		return "", "", false
+	}
+
+	closing := map[byte]byte{'"': '"', '`': '`', '[': ']'}
+	end, ok := closing[def[0]]
+	if !ok {
+		fields := strings.Fields(def)
+		return fields[0], strings.TrimPrefix(def, fields[0]), false
+	}
+
+	for i := 1; i < len(def); i++ {
+		if def[i] != end {
+			continue
+		}
+		// a doubled quote is part of the name
+		if end != ']' && i+1 < len(def) && def[i+1] == end {
+			i++
+			continue
+		}
+		name = strings.ReplaceAll(def[1:i], string([]byte{end, end}), string(end))
+		return name, def[i+1:], true
+	}
+	return "", "", false
+}
+
+var collatePattern = regexp.MustCompile(`(?i)\bCOLLATE\s+["'` + "`" + `\[]?(\w+)`)
+
+// collation named in a column definition, empty for the default BINARY
+func parseCollate(def string) string {
+	match := collatePattern.FindStringSubmatch(def)
+	if match == nil || strings.EqualFold(match[1], "BINARY") {
+		return ""
+	}
+	return strings.ToUpper(match[1])
+}
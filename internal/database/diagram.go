@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// a table with what is needed to draw it in a schema diagram
+type TableSchema struct {
+	Name        string
+	Columns     []Column
+	ForeignKeys []ForeignKey
+}
+
+// returns every table with its columns and foreign keys, sorted by name
+func (m *Manager) GetSchema() ([]TableSchema, error) {
+	tables, err := m.ListTables()
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make([]TableSchema, len(tables))
+	for i, name := range tables {
+		cols, err := m.GetTableSchema(name)
+		if err != nil {
+			return nil, err
+		}
+		fks, err := m.GetForeignKeys(name)
+		if err != nil {
+			return nil, err
+		}
+		schema[i] = TableSchema{name, cols, fks}
+	}
+	return schema, nil
+}
+
+// writes the schema as a Graphviz digraph, one HTML-like table node per table
+// with an edge from each foreign key column to the column it references
+func SchemaDOT(tables []TableSchema) string {
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=plaintext, fontname=\"Helvetica\"];\n\n")
+
+	for _, t := range tables {
+		fmt.Fprintf(&b, "  %s [label=<\n", dotID(t.Name))
+		b.WriteString("    <table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n")
+		fmt.Fprintf(&b, "      <tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", html.EscapeString(t.Name))
+		for _, col := range t.Columns {
+			text := html.EscapeString(strings.TrimSpace(col.Name + " " + col.Type))
+			if col.PK {
+				text = "<u>" + text + "</u>"
+			}
+			fmt.Fprintf(&b, "      <tr><td port=%s align=\"left\">%s</td></tr>\n", dotID(col.Name), text)
+		}
+		b.WriteString("    </table>>];\n")
+	}
+
+	b.WriteString("\n")
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			for i, col := range fk.Columns {
+				to := ""
+				if i < len(fk.RefColumns) {
+					to = ":" + dotID(fk.RefColumns[i])
+				}
+				fmt.Fprintf(&b, "  %s:%s -> %s%s;\n", dotID(t.Name), dotID(col), dotID(fk.RefTable), to)
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotID(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// writes the schema as a Mermaid erDiagram, a parent has zero or more children per foreign key
+func SchemaMermaid(tables []TableSchema) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+
+	for _, t := range tables {
+		fmt.Fprintf(&b, "    %s {\n", mermaidName(t.Name))
+		for _, col := range t.Columns {
+			var keys []string
+			if col.PK {
+				keys = append(keys, "PK")
+			}
+			if col.RefTable != "" {
+				keys = append(keys, "FK")
+			}
+			fmt.Fprintf(&b, "        %s %s", mermaidType(col.Type), mermaidName(col.Name))
+			if len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ","))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+
+	for _, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			fmt.Fprintf(&b, "    %s ||--o{ %s : %q\n",
+				mermaidName(fk.RefTable), mermaidName(t.Name), strings.Join(fk.Columns, ", "))
+		}
+	}
+	return b.String()
+}
+
+var mermaidInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// Mermaid names are single words
+func mermaidName(s string) string {
+	s = strings.Trim(mermaidInvalid.ReplaceAllString(s, "_"), "_")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// declared type without its size, ex. DECIMAL(10, 2) is DECIMAL
+func mermaidType(t string) string {
+	t, _, _ = strings.Cut(t, "(")
+	if t = mermaidName(t); t == "_" {
+		return "ANY"
+	}
+	return t
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DiffKind int
+
+const (
+	DiffChanged DiffKind = iota
+	DiffOnlyLeft
+	DiffOnlyRight
+)
+
+// a row that differs between the two tables of a diff
+type RowDiff struct {
+	Kind    DiffKind
+	Left    []any  // values of TableDiff.Columns, nil when only on the right
+	Right   []any  // nil when only on the left
+	Changed []bool // columns that differ, set for changed rows
+}
+
+// rows of two tables matched on the key of the left one
+type TableDiff struct {
+	Left         string
+	Right        string
+	Columns      []Column // compared columns, the ones both tables have
+	Key          []int    // indexes in Columns of the key
+	LeftColumns  []string // columns only the left table has, not compared
+	RightColumns []string // columns only the right table has
+	Rows         []RowDiff
+	Same         int // rows equal on both sides
+	left, right  tableRef
+}
+
+// counts the rows of each kind
+func (d *TableDiff) Count(kind DiffKind) int {
+	n := 0
+	for _, r := range d.Rows {
+		if r.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// compares the rows of two tables, which can be in different attached databases.
+// rows are matched on the primary key of the left table (the first column when it
+// has none), which the right table must have too. generated columns are skipped
+func (m *Manager) DiffTables(left, right string) (*TableDiff, error) {
+	leftCols, err := m.GetTableSchema(left)
+	if err != nil {
+		return nil, err
+	}
+	rightCols, err := m.GetTableSchema(right)
+	if err != nil {
+		return nil, err
+	}
+	if len(leftCols) == 0 {
+		return nil, fmt.Errorf("Table %s does not exist", left)
+	}
+	if len(rightCols) == 0 {
+		return nil, fmt.Errorf("Table %s does not exist", right)
+	}
+
+	d := &TableDiff{Left: left, Right: right, left: m.resolve(left), right: m.resolve(right)}
+
+	inRight := make(map[string]bool, len(rightCols))
+	for _, col := range rightCols {
+		inRight[strings.ToLower(col.Name)] = true
+	}
+	inLeft := make(map[string]bool, len(leftCols))
+	for _, col := range leftCols {
+		inLeft[strings.ToLower(col.Name)] = true
+		switch {
+		case !inRight[strings.ToLower(col.Name)]:
+			d.LeftColumns = append(d.LeftColumns, col.Name)
+		case col.Generated == "":
+			d.Columns = append(d.Columns, col)
+		}
+	}
+	for _, col := range rightCols {
+		if !inLeft[strings.ToLower(col.Name)] {
+			d.RightColumns = append(d.RightColumns, col.Name)
+		}
+	}
+
+	d.Key = matchKey(d.Columns)
+	for _, k := range matchKey(leftCols) {
+		if !inRight[strings.ToLower(leftCols[k].Name)] {
+			return nil, fmt.Errorf("%s has no column %s to match rows of %s on", right, leftCols[k].Name, left)
+		}
+	}
+	if len(d.Columns) == 0 {
+		return nil, fmt.Errorf("%s and %s have no columns in common", left, right)
+	}
+
+	if err := d.load(m); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// runs the queries finding the rows only on one side and the changed ones
+func (d *TableDiff) load(m *Manager) error {
+	var leftCols, rightCols, match, differ, order []string
+	for i, col := range d.Columns {
+		name := quoteIdentifier(col.Name)
+		leftCols = append(leftCols, rawColumn("l."+name))
+		rightCols = append(rightCols, rawColumn("r."+name))
+		if d.isKey(i) {
+			match = append(match, fmt.Sprintf("r.%s IS l.%s", name, name))
+			order = append(order, name)
+		} else {
+			// IS NOT applies column affinity, so INTEGER 1 and TEXT '1' would be equal
+			differ = append(differ, fmt.Sprintf("typeof(l.%s) IS NOT typeof(r.%s) OR l.%s IS NOT r.%s", name, name, name, name))
+		}
+	}
+	on := strings.Join(match, " AND ")
+	changed := "0"
+	if len(differ) > 0 {
+		changed = strings.Join(differ, " OR ")
+	}
+	from := fmt.Sprintf("%s AS l JOIN %s AS r ON %s", d.left.quoted(), d.right.quoted(), on)
+
+	changedQuery := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s ORDER BY %s",
+		strings.Join(leftCols, ", "), strings.Join(rightCols, ", "), from, changed, "l."+strings.Join(order, ", l."))
+	rows, err := m.queryValues(changedQuery)
+	if err != nil {
+		return fmt.Errorf("Failed to compare rows: %w", err)
+	}
+	for _, row := range rows {
+		r := RowDiff{Kind: DiffChanged, Left: row[:len(d.Columns)], Right: row[len(d.Columns):]}
+		r.Changed = make([]bool, len(d.Columns))
+		for i := range d.Columns {
+			// the same value stored with another type counts as changed, like in the query
+			r.Changed[i] = !sameStored(r.Left[i], r.Right[i])
+		}
+		d.Rows = append(d.Rows, r)
+	}
+
+	// rows of one side without a match on the other
+	only := func(kind DiffKind, a, b tableRef, cols []string) error {
+		query := fmt.Sprintf("SELECT %s FROM %s AS l WHERE NOT EXISTS (SELECT 1 FROM %s AS r WHERE %s) ORDER BY %s",
+			strings.Join(cols, ", "), a.quoted(), b.quoted(), on, "l."+strings.Join(order, ", l."))
+		rows, err := m.queryValues(query)
+		if err != nil {
+			return fmt.Errorf("Failed to find unmatched rows: %w", err)
+		}
+		for _, row := range rows {
+			r := RowDiff{Kind: kind, Left: row}
+			if kind == DiffOnlyRight {
+				r = RowDiff{Kind: kind, Right: row}
+			}
+			d.Rows = append(d.Rows, r)
+		}
+		return nil
+	}
+	if err := only(DiffOnlyLeft, d.left, d.right, leftCols); err != nil {
+		return err
+	}
+	// the right table is l in its own query
+	if err := only(DiffOnlyRight, d.right, d.left, leftCols); err != nil {
+		return err
+	}
+
+	var matched int
+	if err := m.conn().QueryRow("SELECT COUNT(*) FROM " + from).Scan(&matched); err != nil {
+		return fmt.Errorf("Failed to count matching rows: %w", err)
+	}
+	d.Same = matched - d.Count(DiffChanged)
+	return nil
+}
+
+// the primary key, rows of tables without one are matched on their first column
+func matchKey(columns []Column) []int {
+	if keys := keyIndexes(columns); len(keys) > 0 {
+		return keys
+	}
+	if len(columns) > 0 {
+		return []int{0}
+	}
+	return nil
+}
+
+func (d *TableDiff) isKey(i int) bool {
+	for _, k := range d.Key {
+		if k == i {
+			return true
+		}
+	}
+	return false
+}
+
+// statements that make the right table equal to the left one, or the left
+// equal to the right when reverse is set. rows are deleted first so a
+// unique value moving between rows does not conflict
+func (d *TableDiff) SyncSQL(reverse bool) []string {
+	target := d.right
+	if reverse {
+		target = d.left
+	}
+	table := target.quoted()
+
+	names := make([]string, len(d.Columns))
+	quoted := make([]string, len(d.Columns))
+	for i, col := range d.Columns {
+		names[i] = col.Name
+		quoted[i] = quoteIdentifier(col.Name)
+	}
+	where := func(row []any) string {
+		var cols []string
+		var vals []any
+		for _, k := range d.Key {
+			cols = append(cols, names[k])
+			vals = append(vals, row[k])
+		}
+		return MatchWhere(cols, vals)
+	}
+
+	var deletes, updates, inserts []string
+	for _, r := range d.Rows {
+		source, current := r.Left, r.Right
+		if reverse {
+			source, current = r.Right, r.Left
+		}
+		switch {
+		case source == nil:
+			deletes = append(deletes, fmt.Sprintf("DELETE FROM %s WHERE %s", table, where(current)))
+		case current == nil:
+			values := make([]string, len(source))
+			for i, v := range source {
+				values[i] = SQLLiteral(v)
+			}
+			inserts = append(inserts, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				table, strings.Join(quoted, ", "), strings.Join(values, ", ")))
+		default:
+			var set []string
+			for i, changed := range r.Changed {
+				if changed {
+					set = append(set, fmt.Sprintf("%s = %s", quoted[i], SQLLiteral(source[i])))
+				}
+			}
+			if len(set) == 0 {
+				continue
+			}
+			updates = append(updates, fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+				table, strings.Join(set, ", "), where(current)))
+		}
+	}
+	return append(append(deletes, updates...), inserts...)
+}
+
+// runs a query and reads every row
+func (m *Manager) queryValues(query string) ([][]any, error) {
+	rows, err := m.conn().Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	return extractRows(rows, cols)
+}
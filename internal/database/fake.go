@@ -0,0 +1,328 @@
+package database
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	firstNames = []string{
+		"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda",
+		"David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+		"Thomas", "Sarah", "Charles", "Karen", "Daniel", "Nancy", "Matthew", "Lisa",
+		"Anthony", "Betty", "Mark", "Sandra", "Paul", "Ashley", "Steven", "Emily",
+		"Andrew", "Grace", "Kevin", "Olivia", "Brian", "Emma", "George", "Iris",
+	}
+	lastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+		"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor",
+		"Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark",
+		"Lewis", "Robinson", "Walker", "Young", "Allen", "King", "Wright", "Scott",
+	}
+	streets = []string{
+		"Main St", "Oak Ave", "Pine Rd", "Elm St", "Maple Dr", "Cedar Ln", "Birch St",
+		"Willow Way", "Lake View Rd", "Park Ave", "Hill St", "River Rd", "Sunset Blvd",
+	}
+	// city, state and the first digits of its zip codes
+	cities = []struct{ city, state, zip string }{
+		{"Springfield", "IL", "627"}, {"Portland", "OR", "972"}, {"Austin", "TX", "787"},
+		{"Seattle", "WA", "981"}, {"Boston", "MA", "021"}, {"Denver", "CO", "802"},
+		{"Miami", "FL", "331"}, {"Phoenix", "AZ", "850"}, {"Chicago", "IL", "606"},
+		{"Atlanta", "GA", "303"}, {"Nashville", "TN", "372"}, {"Madison", "WI", "537"},
+	}
+	countries = []string{
+		"United States", "Canada", "Mexico", "United Kingdom", "France", "Germany",
+		"Spain", "Italy", "Japan", "Australia", "Brazil", "India",
+	}
+	companyWords = []string{
+		"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Cyberdyne", "Soylent",
+		"Hooli", "Vandelay", "Wonka", "Tyrell", "Pied Piper", "Massive Dynamic",
+	}
+	companySuffixes = []string{"Inc", "LLC", "Corp", "Group", "Ltd", "& Co"}
+	adjectives      = []string{
+		"Classic", "Compact", "Deluxe", "Ergonomic", "Portable", "Premium", "Smart",
+		"Wireless", "Durable", "Rustic", "Modern", "Vintage", "Sleek", "Practical",
+	}
+	nouns = []string{
+		"Laptop", "Mouse", "Chair", "Desk", "Lamp", "Keyboard", "Monitor", "Bookshelf",
+		"Webcam", "Blender", "Headphones", "Backpack", "Kettle", "Speaker", "Notebook",
+	}
+	categories = []string{
+		"Electronics", "Furniture", "Appliances", "Accessories", "Books", "Clothing",
+		"Garden", "Sports", "Toys", "Grocery", "Beauty", "Office", "Audio", "Computers",
+	}
+	statuses = []string{"active", "inactive", "pending", "archived"}
+	colors   = []string{"red", "green", "blue", "black", "white", "yellow", "purple", "orange", "gray"}
+	words    = []string{
+		"great", "quality", "fast", "delivery", "works", "perfect", "price", "value",
+		"recommend", "easy", "setup", "design", "solid", "comfortable", "sturdy", "reliable",
+		"would", "buy", "again", "daily", "use", "light", "small", "large", "excellent",
+		"product", "expected", "better", "than", "simple", "nice", "finish", "good",
+	}
+)
+
+// dates fall in a fixed window so a seed always gives the same rows
+var (
+	fakeTimeFrom = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeTimeSpan = int64(3 * 365 * 24 * 60 * 60)
+)
+
+// people tables get full names in their name column
+var personTable = regexp.MustCompile(`(?i)(user|customer|person|people|employee|author|member|contact|student|staff|client|owner|account)`)
+
+// length of VARCHAR(n) and CHAR(n), 0 if none is declared
+var typeLength = regexp.MustCompile(`\(\s*(\d+)\s*\)`)
+
+// the person a row is about, so its name, email and username agree
+type fakePerson struct {
+	first string
+	last  string
+}
+
+func newFakePerson(r *rand.Rand) fakePerson {
+	return fakePerson{pick(r, firstNames), pick(r, lastNames)}
+}
+
+// a plausible value for the column, guessed from its name, then from its type.
+// the table name tells people from things
+func fakeValue(r *rand.Rand, table string, col Column, p fakePerson) any {
+	if len(col.Enum) > 0 {
+		return col.Enum[r.Intn(len(col.Enum))]
+	}
+	if col.IsBool() {
+		return r.Intn(2)
+	}
+	if col.IsBlob() {
+		b := make([]byte, 8+r.Intn(9))
+		r.Read(b)
+		return b
+	}
+
+	v := fakeByName(r, table, col, p)
+	if v == nil {
+		v = fakeByType(r, col)
+	}
+	if s, ok := v.(string); ok {
+		return truncateToType(s, col.Type)
+	}
+	return v
+}
+
+// value for well known column names, nil if the name says nothing
+func fakeByName(r *rand.Rand, table string, col Column, p fakePerson) any {
+	lower := strings.ToLower(col.Name)
+	name := strings.ReplaceAll(lower, "_", "")
+	numeric := col.Affinity() == "INTEGER" || col.Affinity() == "REAL"
+	first, last := p.first, p.last
+
+	has := func(parts ...string) bool {
+		for _, p := range parts {
+			if strings.Contains(name, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case col.IsTime(), has("timestamp", "birthdate", "dateof"),
+		strings.HasSuffix(name, "date"), strings.HasSuffix(name, "time"),
+		strings.HasSuffix(lower, "_at"), strings.HasSuffix(lower, "_on"):
+		return fakeTime(r, col)
+	case has("email", "mail"):
+		return strings.ToLower(first+"."+last) + "@example.com"
+	case has("username", "login", "handle", "nick"):
+		return strings.ToLower(first[:1] + last)
+	case has("firstname", "givenname", "forename"):
+		return first
+	case has("lastname", "surname", "familyname"):
+		return last
+	case has("fullname", "displayname", "author", "contactname"):
+		return first + " " + last
+	case has("phone", "mobile", "fax"):
+		return fmt.Sprintf("+1 %03d-%03d-%04d", 200+r.Intn(800), r.Intn(1000), r.Intn(10000))
+	case has("url", "website", "link", "homepage"):
+		return fmt.Sprintf("https://www.%s.example.com/%s", strings.ToLower(pick(r, nouns)), pick(r, words))
+	case has("uuid", "guid"):
+		return fakeUUID(r)
+	case name == "ip", has("ipaddr"):
+		return fmt.Sprintf("%d.%d.%d.%d", 10+r.Intn(200), r.Intn(256), r.Intn(256), 1+r.Intn(254))
+	case has("password", "hash", "token", "secret"):
+		return fmt.Sprintf("%016x%016x", r.Uint64(), r.Uint64())
+	case has("street"):
+		return fmt.Sprintf("%d %s", 1+r.Intn(999), pick(r, streets))
+	case has("address"):
+		c := cities[r.Intn(len(cities))]
+		return fmt.Sprintf("%d %s, %s, %s %s%02d", 1+r.Intn(999), pick(r, streets), c.city, c.state, c.zip, r.Intn(100))
+	case has("city", "town"):
+		return cities[r.Intn(len(cities))].city
+	case has("state", "province", "region"):
+		return cities[r.Intn(len(cities))].state
+	case has("zip", "postal", "postcode"):
+		c := cities[r.Intn(len(cities))]
+		return fmt.Sprintf("%s%02d", c.zip, r.Intn(100))
+	case has("country"):
+		return pick(r, countries)
+	case has("company", "organization", "organisation", "employer", "vendor", "supplier"):
+		return pick(r, companyWords) + " " + pick(r, companySuffixes)
+	case has("latitude") || name == "lat":
+		return round2(r.Float64()*180 - 90)
+	case has("longitude") || name == "lng" || name == "lon":
+		return round2(r.Float64()*360 - 180)
+	case has("salary", "income"):
+		return fakeNumber(col, 30000+r.Float64()*120000)
+	case has("price", "amount", "total", "cost", "balance", "fee", "subtotal", "tax"):
+		return fakeNumber(col, 1+r.Float64()*999)
+	case has("quantity", "qty"):
+		return 1 + r.Intn(10)
+	case has("stock", "count", "views", "likes"):
+		return r.Intn(500)
+	case name == "age", strings.HasSuffix(lower, "_age"):
+		return 18 + r.Intn(63)
+	case has("rating", "stars", "score"):
+		return 1 + r.Intn(5)
+	case has("year"):
+		return 1990 + r.Intn(36)
+	case has("percent"):
+		return r.Intn(101)
+	case has("color", "colour"):
+		return pick(r, colors)
+	case has("status"):
+		return pick(r, statuses)
+	case has("sku", "code"):
+		return fmt.Sprintf("%c%c-%05d", 'A'+r.Intn(26), 'A'+r.Intn(26), r.Intn(100000))
+	case numeric:
+		return nil
+	case has("category", "type", "kind", "genre", "tag"):
+		return pick(r, categories)
+	case has("title", "subject", "headline"):
+		return capitalize(sentence(r, 3+r.Intn(3)))
+	case has("description", "comment", "bio", "note", "body", "text", "content", "summary", "message", "review"):
+		return capitalize(sentence(r, 6+r.Intn(8))) + "."
+	case has("name"):
+		switch {
+		case personTable.MatchString(table):
+			return first + " " + last
+		case strings.Contains(strings.ToLower(table), "categor"):
+			return pick(r, categories)
+		case strings.Contains(strings.ToLower(table), "compan"):
+			return pick(r, companyWords) + " " + pick(r, companySuffixes)
+		default:
+			return pick(r, adjectives) + " " + pick(r, nouns)
+		}
+	}
+	return nil
+}
+
+// value from the type affinity alone
+func fakeByType(r *rand.Rand, col Column) any {
+	switch col.Affinity() {
+	case "INTEGER":
+		return r.Intn(1000)
+	case "REAL":
+		return round2(r.Float64() * 1000)
+	case "NUMERIC":
+		if strings.Contains(strings.ToUpper(col.Type), "DEC") {
+			return round2(r.Float64() * 1000)
+		}
+		return r.Intn(1000)
+	default:
+		return sentence(r, 1+r.Intn(3))
+	}
+}
+
+// date, time or both depending on the declared type
+func fakeTime(r *rand.Rand, col Column) any {
+	t := fakeTimeFrom.Add(time.Duration(r.Int63n(fakeTimeSpan)) * time.Second)
+	typ := strings.ToUpper(col.Type)
+	switch {
+	case col.Affinity() == "INTEGER":
+		return t.Unix()
+	case strings.Contains(typ, "DATETIME"), strings.Contains(typ, "TIMESTAMP"):
+		return t.Format("2006-01-02 15:04:05")
+	case strings.Contains(typ, "DATE"):
+		return t.Format("2006-01-02")
+	case strings.Contains(typ, "TIME"):
+		return t.Format("15:04:05")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// money and measures, whole numbers for integer columns
+func fakeNumber(col Column, v float64) any {
+	if col.Affinity() == "INTEGER" {
+		return int64(v)
+	}
+	return round2(v)
+}
+
+func fakeUUID(r *rand.Rand) string {
+	b := make([]byte, 16)
+	r.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func sentence(r *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(r, words)
+	}
+	return strings.Join(parts, " ")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func pick(r *rand.Rand, list []string) string {
+	return list[r.Intn(len(list))]
+}
+
+func round2(v float64) float64 {
+	return float64(int64(v*100)) / 100
+}
+
+// cuts s to the length of VARCHAR(n), SQLite does not enforce it but other
+// tools reading the file might
+func truncateToType(s, colType string) string {
+	match := typeLength.FindStringSubmatch(colType)
+	if match == nil || !strings.Contains(strings.ToUpper(colType), "CHAR") {
+		return s
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n <= 0 || len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// a variant of a value that collided with a UNIQUE column, ex. a number added
+// before the @ of an email or after a username, ex. jsmith2
+func uniqueVariant(v any, n int) any {
+	switch v := v.(type) {
+	case string:
+		if local, domain, ok := strings.Cut(v, "@"); ok {
+			return fmt.Sprintf("%s%d@%s", local, n, domain)
+		}
+		if strings.ToLower(v) == v && !strings.Contains(v, " ") {
+			return fmt.Sprintf("%s%d", v, n)
+		}
+		return fmt.Sprintf("%s %d", v, n)
+	case int:
+		return v + n*1000
+	case int64:
+		return v + int64(n)*1000
+	case float64:
+		return round2(v + float64(n)*1000)
+	}
+	return v
+}
@@ -0,0 +1,415 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rows of one table read from a fixture file
+type Fixture struct {
+	Table string
+	File  string
+	Rows  []map[string]any
+}
+
+// reads a .json, .yaml or .yml fixture file, or every one of them in a
+// directory. a file holds a list of rows for the table named after the file,
+// ex. users.yaml, or an object mapping table names to their lists of rows
+func LoadFixtures(path string) ([]Fixture, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read fixtures: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read fixtures: %w", err)
+		}
+		files = nil
+		for _, e := range entries {
+			if !e.IsDir() && isFixtureFile(e.Name()) {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("No .json, .yaml or .yml files in %s", path)
+		}
+	} else if !isFixtureFile(path) {
+		return nil, fmt.Errorf("Fixture %s is not a .json, .yaml or .yml file", path)
+	}
+
+	var fixtures []Fixture
+	for _, file := range files {
+		f, err := loadFixtureFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, f...)
+	}
+	return fixtures, nil
+}
+
+func isFixtureFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func loadFixtureFile(file string) ([]Fixture, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read fixture: %w", err)
+	}
+
+	var doc any
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		// numbers are kept as written so large integers stay exact
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	} else {
+		var node yaml.Node
+		if err = yaml.Unmarshal(b, &node); err == nil {
+			keepTimestamps(&node)
+			err = node.Decode(&doc)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse fixture %s: %w", filepath.Base(file), err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	switch doc := doc.(type) {
+	case []any:
+		rows, err := fixtureRows(file, name, doc)
+		if err != nil {
+			return nil, err
+		}
+		return []Fixture{{Table: name, File: file, Rows: rows}}, nil
+	case map[string]any:
+		tables := make([]string, 0, len(doc))
+		for table := range doc {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+
+		var fixtures []Fixture
+		for _, table := range tables {
+			list, ok := doc[table].([]any)
+			if !ok {
+				return nil, fmt.Errorf("Fixture %s: %s must be a list of rows", filepath.Base(file), table)
+			}
+			rows, err := fixtureRows(file, table, list)
+			if err != nil {
+				return nil, err
+			}
+			fixtures = append(fixtures, Fixture{Table: table, File: file, Rows: rows})
+		}
+		return fixtures, nil
+	}
+	return nil, fmt.Errorf("Fixture %s must hold a list of rows or an object of tables", filepath.Base(file))
+}
+
+// marks dates and times as strings, so 2024-01-15 is stored as written and not
+// decoded into a time.Time
+func keepTimestamps(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!timestamp" {
+		n.Tag = "!!str"
+	}
+	for _, c := range n.Content {
+		keepTimestamps(c)
+	}
+}
+
+func fixtureRows(file, table string, list []any) ([]map[string]any, error) {
+	rows := make([]map[string]any, len(list))
+	for i, item := range list {
+		row, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("Fixture %s: row %d of %s is not an object", filepath.Base(file), i+1, table)
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// converts a decoded JSON or YAML value to one SQLite stores, objects and
+// lists are stored as JSON text
+func fixtureValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, int, int64, uint64, float64, []byte:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		if f, err := v.Float64(); err == nil {
+			return f, nil
+		}
+		return v.String(), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to convert %v: %w", v, err)
+		}
+		return string(b), nil
+	}
+}
+
+// a fixture ready to insert, columns in table order
+type fixtureTable struct {
+	name    string
+	quoted  string
+	columns []Column
+	pk      []string
+	rows    []map[string]any // keys are lower case column names
+}
+
+// inserts the fixture rows, parents before children. rows whose primary key
+// is taken replace the existing row, so running the same fixtures again
+// changes nothing. replace empties the fixture tables first. it all runs in
+// one transaction
+func (m *Manager) SeedFixtures(fixtures []Fixture, replace bool) ([]Seeded, error) {
+	if m.readOnly {
+		return nil, ErrReadOnly
+	}
+	if m.inTx() {
+		return nil, fmt.Errorf("Commit or roll back the open transaction before seeding")
+	}
+
+	tables, err := m.fixtureTables(fixtures)
+	if err != nil {
+		return nil, err
+	}
+	// tables that do not reference each other keep the order of the fixtures
+	var names []string
+	for _, f := range fixtures {
+		if t := tables[strings.ToLower(f.Table)]; !slices.Contains(names, t.name) {
+			names = append(names, t.name)
+		}
+	}
+	order, err := m.parentsFirst(names, func(string) (bool, error) { return false, nil })
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// children are emptied before the tables they reference
+	if replace {
+		for i := len(order) - 1; i >= 0; i-- {
+			t := tables[strings.ToLower(order[i])]
+			if _, err := tx.Exec("DELETE FROM " + t.quoted); err != nil {
+				return nil, fmt.Errorf("Failed to empty %s: %w", t.name, err)
+			}
+		}
+	}
+
+	var seeded []Seeded
+	for _, name := range order {
+		t := tables[strings.ToLower(name)]
+		s := Seeded{Table: t.name}
+		for i, row := range t.rows {
+			updated, err := t.upsert(tx, row)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to seed row %d of %s: %w", i+1, t.name, err)
+			}
+			if updated {
+				s.Updated++
+			} else {
+				s.Inserted++
+			}
+		}
+		seeded = append(seeded, s)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Failed to commit fixtures: %w", err)
+	}
+	return seeded, nil
+}
+
+// checks the fixtures against their tables, rows of one table in several
+// fixtures are merged
+func (m *Manager) fixtureTables(fixtures []Fixture) (map[string]*fixtureTable, error) {
+	tables := make(map[string]*fixtureTable)
+	for _, f := range fixtures {
+		t := tables[strings.ToLower(f.Table)]
+		if t == nil {
+			columns, err := m.GetTableSchema(f.Table)
+			if err != nil {
+				return nil, fmt.Errorf("Fixture %s: %w", filepath.Base(f.File), err)
+			}
+			pk, err := m.primaryKey(f.Table)
+			if err != nil {
+				return nil, err
+			}
+			t = &fixtureTable{name: f.Table, quoted: m.resolve(f.Table).quoted(), columns: columns, pk: pk}
+			tables[strings.ToLower(f.Table)] = t
+		}
+
+		for i, row := range f.Rows {
+			values := make(map[string]any, len(row))
+			for name, v := range row {
+				if t.column(name) < 0 {
+					return nil, fmt.Errorf("Fixture %s: %s has no column %s", filepath.Base(f.File), t.name, name)
+				}
+				v, err := fixtureValue(v)
+				if err != nil {
+					return nil, fmt.Errorf("Fixture %s: row %d: %w", filepath.Base(f.File), i+1, err)
+				}
+				values[strings.ToLower(name)] = v
+			}
+			t.rows = append(t.rows, values)
+		}
+	}
+	return tables, nil
+}
+
+func (t *fixtureTable) column(name string) int {
+	for i, col := range t.columns {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inserts the row, or updates the row with its primary key. true if it
+// updated one. rows without the whole key are inserted
+func (t *fixtureTable) upsert(q querier, row map[string]any) (bool, error) {
+	var names, marks, set []string
+	var args []any
+	for _, col := range t.columns {
+		v, ok := row[strings.ToLower(col.Name)]
+		if !ok {
+			continue
+		}
+		names = append(names, quoteIdentifier(col.Name))
+		marks = append(marks, "?")
+		args = append(args, v)
+		if !col.PK {
+			set = append(set, fmt.Sprintf("%s = excluded.%s", quoteIdentifier(col.Name), quoteIdentifier(col.Name)))
+		}
+	}
+	if len(names) == 0 {
+		_, err := q.Exec("INSERT INTO " + t.quoted + " DEFAULT VALUES")
+		return false, err
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.quoted, strings.Join(names, ", "), strings.Join(marks, ", "))
+
+	keys := make([]any, len(t.pk))
+	for i, name := range t.pk {
+		keys[i] = row[strings.ToLower(name)]
+		if keys[i] == nil {
+			_, err := q.Exec(query, args...)
+			return false, err
+		}
+	}
+	if len(keys) == 0 {
+		_, err := q.Exec(query, args...)
+		return false, err
+	}
+
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM " + t.quoted + " WHERE " + MatchWhere(t.pk, keys)).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	quotedKey := make([]string, len(t.pk))
+	for i, name := range t.pk {
+		quotedKey[i] = quoteIdentifier(name)
+	}
+	conflict := "DO NOTHING"
+	if len(set) > 0 {
+		conflict = "DO UPDATE SET " + strings.Join(set, ", ")
+	}
+	query += fmt.Sprintf(" ON CONFLICT (%s) %s", strings.Join(quotedKey, ", "), conflict)
+	_, err := q.Exec(query, args...)
+	return exists > 0, err
+}
+
+// runs a SQL script in one transaction and counts the rows it added to each
+// table
+func (m *Manager) SeedScript(path string) ([]Seeded, error) {
+	if m.readOnly {
+		return nil, ErrReadOnly
+	}
+	if m.inTx() {
+		return nil, fmt.Errorf("Commit or roll back the open transaction before seeding")
+	}
+
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read seed script: %w", err)
+	}
+
+	before, err := m.tableCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(string(script)); err != nil {
+		return nil, fmt.Errorf("Seed script %s failed: %w", filepath.Base(path), err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Failed to commit seed script: %w", err)
+	}
+
+	tables, err := m.ListTables()
+	if err != nil {
+		return nil, err
+	}
+	var seeded []Seeded
+	for _, t := range tables {
+		count, err := m.GetRowCount(t)
+		if err != nil {
+			return nil, err
+		}
+		if added := count - before[t]; added > 0 {
+			seeded = append(seeded, Seeded{Table: t, Inserted: added})
+		}
+	}
+	return seeded, nil
+}
+
+// row count of every table
+func (m *Manager) tableCounts() (map[string]int, error) {
+	tables, err := m.ListTables()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(tables))
+	for _, t := range tables {
+		if counts[t], err = m.GetRowCount(t); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// a FOREIGN KEY constraint, composite keys have several columns
+type ForeignKey struct {
+	ID         int
+	Table      string   // table holding the key
+	Columns    []string // columns in Table
+	RefTable   string   // referenced (parent) table
+	RefColumns []string // referenced columns, the parent's primary key if not given
+}
+
+// Returns the foreign keys declared on a table
+func (m *Manager) GetForeignKeys(tableName string) ([]ForeignKey, error) {
+	ref := m.resolve(tableName)
+	rows, err := m.conn().Query(
+		`SELECT id, "table", "from", "to" FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`,
+		ref.name, ref.schema,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	var fks []ForeignKey
+	implicit := false
+	for rows.Next() {
+		var id int
+		var parent, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &parent, &from, &to); err != nil {
+			return nil, fmt.Errorf("Failed to scan foreign key: %w", err)
+		}
+
+		if len(fks) == 0 || fks[len(fks)-1].ID != id {
+			// the parent is in the same schema
+			fks = append(fks, ForeignKey{ID: id, Table: tableName, RefTable: ref.sibling(parent)})
+		}
+		fk := &fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, from)
+		if to.Valid && to.String != "" {
+			fk.RefColumns = append(fk.RefColumns, to.String)
+		} else {
+			implicit = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating foreign keys: %w", err)
+	}
+	rows.Close()
+
+	// REFERENCES parent without columns means the parent's primary key
+	if implicit {
+		for i := range fks {
+			if len(fks[i].RefColumns) > 0 {
+				continue
+			}
+			pk, err := m.primaryKey(fks[i].RefTable)
+			if err != nil {
+				return nil, err
+			}
+			fks[i].RefColumns = pk
+		}
+	}
+
+	return fks, nil
+}
+
+// Returns the foreign keys in other tables that reference this one
+func (m *Manager) GetReferencingKeys(tableName string) ([]ForeignKey, error) {
+	tables, err := m.ListTables()
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []ForeignKey
+	for _, t := range tables {
+		fks, err := m.GetForeignKeys(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range fks {
+			if strings.EqualFold(fk.RefTable, tableName) {
+				refs = append(refs, fk)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// returns the number of rows matching a WHERE clause
+func (m *Manager) CountWhere(tableName, where string) (int, error) {
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", m.resolve(tableName).quoted(), where)
+	if err := m.conn().QueryRow(query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("Failed to count rows: %w", err)
+	}
+	return count, nil
+}
+
+// primary key column names in key order
+func (m *Manager) primaryKey(tableName string) ([]string, error) {
+	ref := m.resolve(tableName)
+	rows, err := m.conn().Query(
+		`SELECT name FROM pragma_table_info(?, ?) WHERE pk > 0 ORDER BY pk`,
+		ref.name, ref.schema,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get primary key: %w", err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Failed to scan primary key: %w", err)
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+// builds a WHERE clause matching the columns to the values, ex. "id" = 3
+func MatchWhere(columns []string, values []any) string {
+	conds := make([]string, len(columns))
+	for i, col := range columns {
+		if values[i] == nil {
+			conds[i] = quoteIdentifier(col) + " IS NULL"
+		} else {
+			conds[i] = quoteIdentifier(col) + " = " + SQLLiteral(values[i])
+		}
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// a row of a referenced table offered in a foreign key picker
+type RefOption struct {
+	Key   any
+	Label string // empty if the table has no label column
+}
+
+// columns tried in order when no label column is configured
+var labelNames = []string{"name", "username", "title", "label", "display_name", "full_name", "email"}
+
+// returns the column that describes a row of the table for people, ex. users.name,
+// or "" if there is none
+func (m *Manager) LabelColumn(tableName string) (string, error) {
+	for table, col := range m.labels {
+		if strings.EqualFold(table, tableName) {
+			return col, nil
+		}
+	}
+
+	cols, err := m.GetTableSchema(tableName)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range labelNames {
+		for _, col := range cols {
+			if strings.EqualFold(col.Name, name) {
+				return col.Name, nil
+			}
+		}
+	}
+	for _, col := range cols {
+		if !col.PK && col.Affinity() == "TEXT" && strings.Contains(strings.ToLower(col.Name), "name") {
+			return col.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// returns up to limit keys of the referenced table with their labels, sorted by label
+func (m *Manager) GetRefOptions(tableName, keyColumn string, limit int) ([]RefOption, error) {
+	label, err := m.LabelColumn(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := quoteIdentifier(keyColumn)
+	table := m.resolve(tableName).quoted()
+	query := fmt.Sprintf("SELECT %s, NULL FROM %s ORDER BY %s LIMIT ?", rawColumn(key), table, key)
+	if label != "" {
+		query = fmt.Sprintf("SELECT %s, %s FROM %s ORDER BY %s, %s LIMIT ?",
+			rawColumn(key), quoteIdentifier(label), table, quoteIdentifier(label), key)
+	}
+
+	rows, err := m.conn().Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get %s keys: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var opts []RefOption
+	for rows.Next() {
+		var opt RefOption
+		var text any
+		if err := rows.Scan(&opt.Key, &text); err != nil {
+			return nil, fmt.Errorf("Failed to scan %s key: %w", tableName, err)
+		}
+		if text != nil {
+			opt.Label = valToString(text)
+		}
+		opts = append(opts, opt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating %s keys: %w", tableName, err)
+	}
+	return opts, nil
+}
@@ -0,0 +1,398 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// how many rows GenerateRows adds and from which seed
+type GenerateOptions struct {
+	Rows int   // rows added to each table
+	Seed int64 // the same seed on the same data gives the same rows, 0 picks one
+}
+
+// tries at a row before giving up, a try fails when a constraint rejects it
+const generateTries = 10
+
+// parents with more keys than this are sampled from the first ones
+const generateMaxKeys = 10000
+
+// what filling a table needs, read before the transaction starts because
+// reads outside of it would wait for its connection
+type genTable struct {
+	name    string
+	quoted  string
+	columns []Column // columns given a value, the rowid alias is left to SQLite
+	rowid   string   // INTEGER PRIMARY KEY column, empty if none
+	fks     []genKey
+	unique  []int // indexes in columns of single column UNIQUE and PRIMARY KEY
+}
+
+// a foreign key of a table being filled with the rows its values can come from
+type genKey struct {
+	ForeignKey
+	parent   string  // quoted parent table
+	columns  []int   // indexes in genTable.columns, -1 for the rowid alias
+	nullable bool    // every column may be NULL
+	self     bool    // the table references itself
+	keys     [][]any // parent key values
+}
+
+// fills the tables with opts.Rows rows of made up data each. values are
+// guessed from column names and types and respect NOT NULL, UNIQUE, CHECK IN
+// enumerations and foreign keys. empty parent tables are filled first, rows
+// of parents that have some are reused. it all runs in one transaction, or a
+// savepoint of the open one in transaction mode. returns the seed used
+func (m *Manager) GenerateRows(tables []string, opts GenerateOptions) ([]Seeded, int64, error) {
+	if opts.Rows <= 0 {
+		return nil, 0, fmt.Errorf("Number of rows must be positive")
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	order, err := m.generationOrder(tables)
+	if err != nil {
+		return nil, seed, err
+	}
+	plan := make([]*genTable, len(order))
+	for i, t := range order {
+		if plan[i], err = m.genTable(t); err != nil {
+			return nil, seed, err
+		}
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	var generated []Seeded
+	err = m.atomic("generate_rows", func(q querier) error {
+		for _, t := range plan {
+			n, err := t.fill(q, r, opts.Rows)
+			if err != nil {
+				return err
+			}
+			generated = append(generated, Seeded{Table: t.name, Inserted: n})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, seed, err
+	}
+
+	for _, g := range generated {
+		m.record(Change{
+			Kind:  ChangeStatement,
+			Table: g.Table,
+			SQL:   fmt.Sprintf("INSERT INTO %s ... -- %d generated rows, seed %d", m.resolve(g.Table).quoted(), g.Inserted, seed),
+		})
+	}
+	return generated, seed, nil
+}
+
+// the tables with the empty tables they reference, parents before children.
+// cycles are broken where they are found, their keys get NULL or existing rows
+func (m *Manager) generationOrder(tables []string) ([]string, error) {
+	return m.parentsFirst(tables, func(parent string) (bool, error) {
+		count, err := m.GetRowCount(parent)
+		return count == 0, err
+	})
+}
+
+// sorts the tables so the tables they reference come first. referenced tables
+// that are not listed are added when include says so, cycles are broken where
+// they are found
+func (m *Manager) parentsFirst(tables []string, include func(parent string) (bool, error)) ([]string, error) {
+	var order []string
+	seen := make(map[string]bool)
+	requested := make(map[string]bool)
+	for _, t := range tables {
+		requested[strings.ToLower(t)] = true
+	}
+
+	var visit func(t string) error
+	visit = func(t string) error {
+		if seen[strings.ToLower(t)] {
+			return nil
+		}
+		seen[strings.ToLower(t)] = true
+
+		fks, err := m.GetForeignKeys(t)
+		if err != nil {
+			return err
+		}
+		for _, fk := range fks {
+			ok := requested[strings.ToLower(fk.RefTable)]
+			if !ok {
+				if ok, err = include(fk.RefTable); err != nil {
+					return err
+				}
+			}
+			if ok {
+				if err := visit(fk.RefTable); err != nil {
+					return err
+				}
+			}
+		}
+		order = append(order, t)
+		return nil
+	}
+
+	for _, t := range tables {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func (m *Manager) genTable(tableName string) (*genTable, error) {
+	def, err := m.GetTableDefinition(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if def.Type != "table" && def.Type != "virtual" {
+		return nil, fmt.Errorf("Cannot generate rows for %s, it is a %s", tableName, def.Type)
+	}
+
+	t := &genTable{name: tableName, quoted: m.resolve(tableName).quoted()}
+
+	var pk []Column
+	for _, col := range def.Columns {
+		if col.PK {
+			pk = append(pk, col)
+		}
+	}
+	if len(pk) == 1 && strings.EqualFold(pk[0].Type, "INTEGER") && !def.WithoutRowid {
+		t.rowid = pk[0].Name
+	}
+
+	fks, err := m.GetForeignKeys(tableName)
+	if err != nil {
+		return nil, err
+	}
+	inKey := func(name string) bool {
+		for _, fk := range fks {
+			for _, c := range fk.Columns {
+				if strings.EqualFold(c, name) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, col := range def.Columns {
+		switch {
+		case col.Hidden, col.Generated != "":
+			continue
+		case strings.EqualFold(col.Name, t.rowid) && !inKey(col.Name):
+			continue
+		}
+		if col.Unique || col.PK && len(pk) == 1 {
+			t.unique = append(t.unique, len(t.columns))
+		}
+		t.columns = append(t.columns, col)
+	}
+
+	for _, fk := range fks {
+		key := genKey{
+			ForeignKey: fk,
+			parent:     m.resolve(fk.RefTable).quoted(),
+			nullable:   true,
+			self:       strings.EqualFold(fk.RefTable, tableName),
+		}
+		for _, name := range fk.Columns {
+			i := t.column(name)
+			key.columns = append(key.columns, i)
+			if i >= 0 && t.columns[i].NotNull {
+				key.nullable = false
+			}
+		}
+		t.fks = append(t.fks, key)
+	}
+	return t, nil
+}
+
+// index of the named column in t.columns, -1 if it is not given a value
+func (t *genTable) column(name string) int {
+	for i, col := range t.columns {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inserts n rows, the parents are read through q so rows generated for them
+// just before are found
+func (t *genTable) fill(q querier, r *rand.Rand, n int) (int, error) {
+	for i := range t.fks {
+		keys, err := parentKeys(q, t.fks[i])
+		if err != nil {
+			return 0, err
+		}
+		t.fks[i].keys = keys
+	}
+
+	taken := make(map[int]map[string]bool)
+	for _, i := range t.unique {
+		values, err := columnValues(q, t.quoted, t.columns[i].Name)
+		if err != nil {
+			return 0, err
+		}
+		taken[i] = values
+	}
+
+	names := make([]string, len(t.columns))
+	marks := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		names[i] = quoteIdentifier(col.Name)
+		marks[i] = "?"
+	}
+	query := fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", t.quoted)
+	if len(t.columns) > 0 {
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			t.quoted, strings.Join(names, ", "), strings.Join(marks, ", "))
+	}
+
+	for row := 0; row < n; row++ {
+		var err error
+		var res sql.Result
+		var values []any
+		for try := 0; try < generateTries; try++ {
+			if values, err = t.row(r, taken); err != nil {
+				return 0, err
+			}
+			if res, err = q.Exec(query, values...); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return 0, fmt.Errorf("Failed to generate rows for %s: %w", t.name, err)
+		}
+
+		for _, i := range t.unique {
+			taken[i][fmt.Sprint(values[i])] = true
+		}
+
+		// later rows can reference this one
+		for i := range t.fks {
+			if fk := &t.fks[i]; fk.self {
+				if key := t.selfKey(fk, values, res); key != nil {
+					fk.keys = append(fk.keys, key)
+				}
+			}
+		}
+	}
+	return n, nil
+}
+
+// values of one row, foreign keys take the key of a random parent row
+func (t *genTable) row(r *rand.Rand, taken map[int]map[string]bool) ([]any, error) {
+	values := make([]any, len(t.columns))
+	filled := make([]bool, len(t.columns))
+
+	for _, fk := range t.fks {
+		var key []any
+		switch {
+		case len(fk.keys) > 0 && !(fk.nullable && r.Intn(10) == 0):
+			key = fk.keys[r.Intn(len(fk.keys))]
+		case !fk.nullable && !fk.self:
+			return nil, fmt.Errorf("Failed to generate rows for %s: %s has no rows to reference", t.name, fk.RefTable)
+		case !fk.nullable:
+			return nil, fmt.Errorf("Failed to generate rows for %s: %s references itself with NOT NULL columns", t.name, strings.Join(fk.Columns, ", "))
+		}
+		for n, i := range fk.columns {
+			if i < 0 {
+				continue
+			}
+			if key != nil {
+				values[i] = key[n]
+			}
+			filled[i] = true
+		}
+	}
+
+	person := newFakePerson(r)
+	for i, col := range t.columns {
+		if filled[i] {
+			continue
+		}
+		if !col.NotNull && !col.PK && r.Intn(10) == 0 {
+			continue
+		}
+		v := fakeValue(r, t.name, col, person)
+		if values, ok := taken[i]; ok {
+			base := v
+			for n := 2; values[fmt.Sprint(v)] && n < generateTries*100; n++ {
+				v = uniqueVariant(base, n)
+			}
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// the key the inserted row is referenced by, from its values or its rowid
+func (t *genTable) selfKey(fk *genKey, values []any, res sql.Result) []any {
+	key := make([]any, len(fk.RefColumns))
+	for n, name := range fk.RefColumns {
+		if i := t.column(name); i >= 0 {
+			key[n] = values[i]
+			continue
+		}
+		if !strings.EqualFold(name, t.rowid) {
+			return nil
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil
+		}
+		key[n] = id
+	}
+	return key
+}
+
+// key values of the parent rows, in key order so a seed picks the same ones
+func parentKeys(q querier, fk genKey) ([][]any, error) {
+	cols := make([]string, len(fk.RefColumns))
+	var notNull []string
+	for i, c := range fk.RefColumns {
+		cols[i] = quoteIdentifier(c)
+		notNull = append(notNull, cols[i]+" IS NOT NULL")
+	}
+	list := strings.Join(cols, ", ")
+	raw := make([]string, len(cols))
+	for i, c := range cols {
+		raw[i] = rawColumn(c)
+	}
+	rows, err := q.Query(fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s ORDER BY %s LIMIT %d",
+		strings.Join(raw, ", "), fk.parent, strings.Join(notNull, " AND "), list, generateMaxKeys))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read keys of %s: %w", fk.RefTable, err)
+	}
+	defer rows.Close()
+	return extractRows(rows, fk.RefColumns)
+}
+
+// the values a column already holds, to keep UNIQUE columns unique
+func columnValues(q querier, table, column string) (map[string]bool, error) {
+	rows, err := q.Query(fmt.Sprintf("SELECT %s FROM %s", quoteIdentifier(column), table))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]bool)
+	for rows.Next() {
+		var v any
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("Failed to scan %s: %w", column, err)
+		}
+		values[fmt.Sprint(v)] = true
+	}
+	return values, rows.Err()
+}
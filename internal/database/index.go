@@ -0,0 +1,189 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// an index from PRAGMA index_list and index_xinfo
+type Index struct {
+	Name    string
+	Unique  bool
+	Origin  string // c: CREATE INDEX, u: UNIQUE constraint, pk: PRIMARY KEY
+	Partial bool
+	Where   string // condition of a partial index
+	Columns []IndexColumn
+	SQL     string // CREATE INDEX statement, empty for automatic indexes
+}
+
+type IndexColumn struct {
+	Name    string // empty for an expression
+	Desc    bool
+	Collate string
+}
+
+// true if the index was made with CREATE INDEX and can be dropped
+func (i Index) Droppable() bool {
+	return i.Origin == "c"
+}
+
+// columns as written in CREATE INDEX, ex. "user_id", "order_date" DESC
+func (i Index) ColumnList() string {
+	cols := make([]string, len(i.Columns))
+	for n, col := range i.Columns {
+		cols[n] = col.String()
+	}
+	return strings.Join(cols, ", ")
+}
+
+func (c IndexColumn) String() string {
+	s := "<expr>"
+	if c.Name != "" {
+		s = quoteIdentifier(c.Name)
+	}
+	if c.Collate != "" && !strings.EqualFold(c.Collate, "BINARY") {
+		s += " COLLATE " + c.Collate
+	}
+	if c.Desc {
+		s += " DESC"
+	}
+	return s
+}
+
+var partialWherePattern = regexp.MustCompile(`(?is)\)\s*WHERE\s+(.+?)\s*;?\s*$`)
+
+// Returns the indexes of a table with their key columns
+func (m *Manager) GetIndexes(tableName string) ([]Index, error) {
+	ref := m.resolve(tableName)
+	rows, err := m.conn().Query(
+		`SELECT l.name, l."unique", l.origin, l.partial, s.sql
+		FROM pragma_index_list(?, ?) l LEFT JOIN `+ref.master()+` s ON s.type = 'index' AND s.name = l.name
+		ORDER BY l.name`,
+		ref.name, ref.schema,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var idx Index
+		var unique, partial int
+		var stmt sql.NullString
+		if err := rows.Scan(&idx.Name, &unique, &idx.Origin, &partial, &stmt); err != nil {
+			return nil, fmt.Errorf("Failed to scan index: %w", err)
+		}
+		idx.Unique = unique == 1
+		idx.Partial = partial == 1
+		idx.SQL = stmt.String
+		if match := partialWherePattern.FindStringSubmatch(stmt.String); idx.Partial && match != nil {
+			idx.Where = match[1]
+		}
+		indexes = append(indexes, idx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating indexes: %w", err)
+	}
+	rows.Close()
+
+	for i := range indexes {
+		cols, err := m.indexColumns(indexes[i].Name, ref.schema)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i].Columns = cols
+	}
+	return indexes, nil
+}
+
+func (m *Manager) indexColumns(indexName, schema string) ([]IndexColumn, error) {
+	rows, err := m.conn().Query(
+		`SELECT name, "desc", coll FROM pragma_index_xinfo(?, ?) WHERE key = 1 ORDER BY seqno`,
+		indexName, schema,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get index columns: %w", err)
+	}
+	defer rows.Close()
+
+	var cols []IndexColumn
+	for rows.Next() {
+		var col IndexColumn
+		var name, coll sql.NullString
+		var desc int
+		if err := rows.Scan(&name, &desc, &coll); err != nil {
+			return nil, fmt.Errorf("Failed to scan index column: %w", err)
+		}
+		col.Name = name.String
+		col.Desc = desc == 1
+		col.Collate = coll.String
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
+}
+
+// builds a CREATE INDEX statement, where is left out if empty. the schema of a
+// qualified table goes on the index name, SQLite does not allow it on the table
+func (m *Manager) CreateIndexSQL(idx Index, tableName string) string {
+	create := "CREATE INDEX"
+	if idx.Unique {
+		create = "CREATE UNIQUE INDEX"
+	}
+	ref := m.resolve(tableName)
+	name := quoteIdentifier(idx.Name)
+	if ref.qualified {
+		name = quoteIdentifier(ref.schema) + "." + name
+	}
+	stmt := fmt.Sprintf("%s %s ON %s (%s)", create, name, quoteIdentifier(ref.name), idx.ColumnList())
+	if where := strings.TrimSpace(idx.Where); where != "" {
+		stmt += " WHERE " + where
+	}
+	return stmt
+}
+
+// builds a DROP INDEX statement for an index of the table. the index is named
+// with the table's schema, a bare name is looked up in temp and main first
+func (m *Manager) DropIndexSQL(indexName, tableName string) string {
+	return "DROP INDEX " + quoteIdentifier(m.resolve(tableName).schema) + "." + quoteIdentifier(indexName)
+}
+
+// parses index columns typed as a list, ex. "user_id, order_date DESC"
+func ParseIndexColumns(text string, columns []Column) ([]IndexColumn, error) {
+	var cols []IndexColumn
+	for _, part := range strings.Split(text, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		col := IndexColumn{}
+		if len(fields) > 1 {
+			switch strings.ToUpper(fields[len(fields)-1]) {
+			case "DESC":
+				col.Desc = true
+				fields = fields[:len(fields)-1]
+			case "ASC":
+				fields = fields[:len(fields)-1]
+			}
+		}
+
+		name := strings.Trim(strings.Join(fields, " "), "\"`[]")
+		for _, c := range columns {
+			if strings.EqualFold(c.Name, name) {
+				col.Name = c.Name
+			}
+		}
+		if col.Name == "" {
+			return nil, fmt.Errorf("No column %q", name)
+		}
+		cols = append(cols, col)
+	}
+
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("Pick at least one column")
+	}
+	return cols, nil
+}
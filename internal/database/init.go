@@ -2,18 +2,49 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 
 	_ "modernc.org/sqlite"
 )
 
+var ErrReadOnly = errors.New("Database is open in read-only mode")
+
 type Manager struct {
-	db   *sql.DB
-	path string
+	db         *sql.DB
+	path       string
+	readOnly   bool
+	create     bool              // attached files may be created too
+	labels     map[string]string // label column by table, see LabelColumn
+	migrations string            // see MigrationsDir
+
+	mu      sync.Mutex
+	txMode  bool     // hold writes in a transaction until Commit
+	tx      *sql.Tx  // open transaction, nil if none
+	pending []Change // changes made inside tx
+	undo    []Change // changes that can be reverted
+	redo    []Change // reverted changes that can be reapplied
 }
 
-func NewManager(path string) (*Manager, error) {
-	db, err := sql.Open("sqlite", path)
+// options used when opening a database
+type Options struct {
+	ReadOnly bool // open with mode=ro and refuse any writes
+	Create   bool // allow creating the file if it does not exist
+	// column shown for rows of a table in foreign key pickers, guessed if not set
+	Labels map[string]string
+	// directory of NNNN_name.up.sql/.down.sql files, migrations next to the database if not set
+	Migrations string
+}
+
+func NewManager(path string, opts Options) (*Manager, error) {
+	if err := checkPath(path, opts); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dsn(path, opts))
 	if err != nil {
 		return nil, fmt.Errorf("Failed to open database: %w", err)
 	}
@@ -23,12 +54,62 @@ func NewManager(path string) (*Manager, error) {
 		return nil, fmt.Errorf("Failed to ping database: %w", err)
 	}
 
+	// temp tables only exist on the connection that made them, so every
+	// statement goes through the same one
+	db.SetMaxOpenConns(1)
+
 	return &Manager{
-		db: db,
-		path: path,
+		db:         db,
+		path:       path,
+		readOnly:   opts.ReadOnly,
+		create:     opts.Create,
+		labels:     opts.Labels,
+		migrations: opts.Migrations,
 	}, nil
 }
 
 func (m *Manager) Close() error {
+	// uncommitted changes are discarded
+	m.Rollback()
 	return m.db.Close()
 }
+
+// true if the database was opened with -readonly
+func (m *Manager) ReadOnly() bool {
+	return m.readOnly
+}
+
+// refuses a missing database file unless it may be created
+func checkPath(path string, opts Options) error {
+	if path == ":memory:" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("Failed to stat database: %w", err)
+		}
+		if !opts.Create || opts.ReadOnly {
+			return fmt.Errorf("Database %s does not exist (use -create to create it)", path)
+		}
+	}
+	return nil
+}
+
+// builds a sqlite URI so the open mode can be set explicitly
+func dsn(path string, opts Options) string {
+	if path == ":memory:" {
+		return path
+	}
+
+	mode := "rw"
+	if opts.ReadOnly {
+		mode = "ro"
+	} else if opts.Create {
+		mode = "rwc"
+	}
+
+	// '?' and '#' would otherwise end the path portion of the URI
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+
+	return fmt.Sprintf("file:%s?mode=%s", escaped, mode)
+}
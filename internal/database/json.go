@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// returns rows matching a WHERE clause typed by the user. the clause is raw
+// SQL and runs as typed, nothing in it is escaped
+func (m *Manager) FilterTable(tableName, where string, limit, offset int) ([][]any, error) {
+	if strings.TrimSpace(where) == "" {
+		return m.GetTableData(tableName, limit, offset)
+	}
+
+	list, err := m.selectList(tableName)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT ? OFFSET ?", list, m.resolve(tableName).quoted(), where)
+
+	rows, err := m.conn().Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to filter table: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get columns: %w", err)
+	}
+
+	res, err := extractRows(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating filtered rows: %w", err)
+	}
+
+	return res, nil
+}
+
+// evaluates a JSON path against a document with SQLite's json_extract
+func (m *Manager) JSONExtract(doc, path string) (any, error) {
+	var val any
+	err := m.conn().QueryRow("SELECT json_extract(?, ?)", doc, path).Scan(&val)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to extract %s: %w", path, err)
+	}
+	return val, nil
+}
+
+// builds a Data tab filter matching rows where the path in the column equals value.
+// objects and arrays can't be compared, so only their presence is checked
+func JSONFilter(column, path string, value any, isContainer bool) string {
+	extract := fmt.Sprintf("json_extract(%s, %s)", quoteIdentifier(column), QuoteLiteral(path))
+
+	switch v := value.(type) {
+	case nil:
+		if isContainer {
+			return extract + " IS NOT NULL"
+		}
+		return extract + " IS NULL"
+	case bool: // json_extract returns true/false as 1/0
+		if v {
+			return extract + " = 1"
+		}
+		return extract + " = 0"
+	case float64:
+		return extract + " = " + strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return extract + " = " + QuoteLiteral(v)
+	default:
+		return extract + " = " + QuoteLiteral(valToString(v))
+	}
+}
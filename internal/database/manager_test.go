@@ -0,0 +1,1109 @@
+package database_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"dbtui/internal/database"
+)
+
+// table names that break statements built without quoting
+var hostileNames = []string{
+	"order",
+	"my table",
+	"dash-name",
+	`quo"te`,
+	"it's",
+	"[bracket]",
+	"back`tick",
+	"semi;colon",
+	"dot.ted",
+	"main",
+	"naïve 表",
+	`x"); DROP TABLE sentinel; --`,
+}
+
+func newManager(t *testing.T) *database.Manager {
+	t.Helper()
+	m, err := database.NewManager(filepath.Join(t.TempDir(), "test.db"), database.Options{Create: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func mustExec(t *testing.T, m *database.Manager, query string) {
+	t.Helper()
+	if _, _, err := m.ExecuteQuery(query); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func rowCount(t *testing.T, m *database.Manager, table string) int {
+	t.Helper()
+	n, err := m.GetRowCount(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestHostileTableNames(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE sentinel (id INTEGER)")
+
+	for _, name := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			mustExec(t, m, "CREATE TABLE "+quote(name)+` (
+				id INTEGER PRIMARY KEY,
+				"full name" TEXT COLLATE NOCASE UNIQUE,
+				parent INTEGER REFERENCES `+quote(name)+`(id)
+			)`)
+			testTable(t, m, name)
+		})
+	}
+
+	tables, err := m.ListTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(tables, "sentinel") {
+		t.Errorf("sentinel table was dropped, tables: %q", tables)
+	}
+}
+
+// runs every Manager method taking a table name against it
+func testTable(t *testing.T, m *database.Manager, name string) {
+	tables, err := m.ListTables()
+	if err != nil || !slices.Contains(tables, name) {
+		t.Fatalf("ListTables() = %q, %v", tables, err)
+	}
+
+	info, err := m.GetTableInfo(name)
+	if err != nil || info.Type != "table" || info.ColumnCount != 3 {
+		t.Fatalf("GetTableInfo() = %+v, %v", info, err)
+	}
+
+	cols, err := m.GetTableSchema(name)
+	if err != nil || len(cols) != 3 {
+		t.Fatalf("GetTableSchema() = %+v, %v", cols, err)
+	}
+	if !cols[0].PK || !cols[1].Unique || cols[1].Collate != "NOCASE" || cols[2].RefTable != name {
+		t.Errorf("GetTableSchema() = %+v", cols)
+	}
+
+	def, err := m.GetTableDefinition(name)
+	if err != nil || !strings.HasPrefix(def.SQL, "CREATE TABLE") || len(def.Columns) != 3 {
+		t.Errorf("GetTableDefinition() = %+v, %v", def, err)
+	}
+
+	for _, row := range [][]any{{database.Default, "Ann", nil}, {database.Default, "Bob", int64(1)}} {
+		if err := m.InsertRow(name, cols, row); err != nil {
+			t.Fatalf("InsertRow(%v) = %v", row, err)
+		}
+	}
+	if n := rowCount(t, m, name); n != 2 {
+		t.Fatalf("GetRowCount() = %d after two inserts", n)
+	}
+
+	rows, err := m.GetTableData(name, 10, 0)
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("GetTableData() = %v, %v", rows, err)
+	}
+	if found, err := m.SearchTable(name, "bo", 10, 0); err != nil || len(found) != 1 {
+		t.Errorf("SearchTable() = %v, %v", found, err)
+	}
+	where := database.MatchWhere([]string{"full name"}, []any{"Ann"})
+	if found, err := m.FilterTable(name, where, 10, 0); err != nil || len(found) != 1 {
+		t.Errorf("FilterTable(%s) = %v, %v", where, found, err)
+	}
+
+	edited := []any{rows[0][0], "Anna", nil}
+	if err := m.EditRow(name, cols, rows[0], edited); err != nil {
+		t.Fatalf("EditRow() = %v", err)
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Errorf("Undo() = %v", err)
+	}
+	if _, err := m.Redo(); err != nil {
+		t.Errorf("Redo() = %v", err)
+	}
+	if n, err := m.CountWhere(name, database.MatchWhere([]string{"full name"}, []any{"Anna"})); err != nil || n != 1 {
+		t.Errorf("CountWhere() = %d, %v after edit", n, err)
+	}
+
+	fks, err := m.GetForeignKeys(name)
+	if err != nil || len(fks) != 1 || fks[0].RefTable != name || !slices.Equal(fks[0].RefColumns, []string{"id"}) {
+		t.Errorf("GetForeignKeys() = %+v, %v", fks, err)
+	}
+	refs, err := m.GetReferencingKeys(name)
+	if err != nil || len(refs) != 1 || refs[0].Table != name {
+		t.Errorf("GetReferencingKeys() = %+v, %v", refs, err)
+	}
+	if label, err := m.LabelColumn(name); err != nil || label != "full name" {
+		t.Errorf("LabelColumn() = %q, %v", label, err)
+	}
+	if opts, err := m.GetRefOptions(name, "id", 10); err != nil || len(opts) != 2 {
+		t.Errorf("GetRefOptions() = %+v, %v", opts, err)
+	}
+
+	idxCols, err := database.ParseIndexColumns("parent, full name DESC", cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := database.Index{Name: name + " idx", Columns: idxCols, Where: "parent IS NOT NULL"}
+	mustExec(t, m, m.CreateIndexSQL(idx, name))
+	indexes, err := m.GetIndexes(name)
+	if err != nil || !slices.ContainsFunc(indexes, func(i database.Index) bool {
+		return i.Name == idx.Name && i.Partial && len(i.Columns) == 2 && i.Columns[1].Desc
+	}) {
+		t.Errorf("GetIndexes() = %+v, %v", indexes, err)
+	}
+	mustExec(t, m, m.DropIndexSQL(idx.Name, name))
+
+	create := strings.Replace(m.TriggerTemplate(name), "BEGIN\n  \nEND", "BEGIN SELECT 1; END", 1)
+	if err := m.SaveTrigger(name, "", create); err != nil {
+		t.Fatalf("SaveTrigger(%s) = %v", create, err)
+	}
+	triggers, err := m.GetTriggers(name)
+	if err != nil || len(triggers) != 1 || triggers[0].Timing != "AFTER" || triggers[0].Event != "INSERT" {
+		t.Fatalf("GetTriggers() = %+v, %v", triggers, err)
+	}
+	replace := strings.Replace(create, "AFTER INSERT", "BEFORE DELETE", 1)
+	if err := m.SaveTrigger(name, triggers[0].Name, replace); err != nil {
+		t.Errorf("SaveTrigger() replacing = %v", err)
+	}
+	if triggers, err := m.GetTriggers(name); err != nil || len(triggers) != 1 || triggers[0].Event != "DELETE" {
+		t.Errorf("GetTriggers() after replace = %+v, %v", triggers, err)
+	}
+	mustExec(t, m, m.DropTriggerSQL(name, triggers[0].Name))
+
+	if err := m.DeleteRow(name, cols, rows[1]); err != nil {
+		t.Fatalf("DeleteRow() = %v", err)
+	}
+	if n := rowCount(t, m, name); n != 1 {
+		t.Errorf("GetRowCount() = %d after delete", n)
+	}
+
+	schema, err := m.GetSchema()
+	if err != nil || !slices.ContainsFunc(schema, func(s database.TableSchema) bool { return s.Name == name }) {
+		t.Errorf("GetSchema() = %+v, %v", schema, err)
+	}
+	if sizes, err := m.GetTableSizes(); err != nil || sizes != nil && sizes[name] <= 0 {
+		t.Errorf("GetTableSizes() = %v, %v", sizes, err)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+	cols, err := m.GetTableSchema("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Undo(); err == nil {
+		t.Error("Undo() with nothing to undo succeeded")
+	}
+
+	if err := m.InsertRow("notes", cols, []any{int64(1), "draft"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EditRow("notes", cols, []any{int64(1), "draft"}, []any{int64(1), "final"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the row changed behind the edit, undoing it would lose that change
+	mustExec(t, m, "UPDATE notes SET body = 'other' WHERE id = 1")
+	if _, err := m.Undo(); !errors.Is(err, database.ErrConflict) {
+		t.Fatalf("Undo() of a changed row = %v, want ErrConflict", err)
+	}
+	// the failed undo is kept and works once the row is back
+	mustExec(t, m, "UPDATE notes SET body = 'final' WHERE id = 1")
+	if c, err := m.Undo(); err != nil || c.Kind != database.ChangeUpdate {
+		t.Fatalf("Undo() after the conflict = %+v, %v", c, err)
+	}
+	if n, _ := m.CountWhere("notes", "body = 'draft'"); n != 1 {
+		t.Error("Undo() did not restore the edited value")
+	}
+	if c, err := m.Undo(); err != nil || c.Kind != database.ChangeInsert || rowCount(t, m, "notes") != 0 {
+		t.Fatalf("Undo() of the insert = %+v, %v", c, err)
+	}
+
+	// redo fails while another row holds the key, and is kept too
+	mustExec(t, m, "INSERT INTO notes VALUES (1, 'squatter')")
+	if _, err := m.Redo(); !errors.Is(err, database.ErrConflict) {
+		t.Fatalf("Redo() onto a taken key = %v, want ErrConflict", err)
+	}
+	mustExec(t, m, "DELETE FROM notes")
+	for _, want := range []string{"draft", "final"} {
+		if _, err := m.Redo(); err != nil {
+			t.Fatalf("Redo() = %v", err)
+		}
+		if n, _ := m.CountWhere("notes", "body = "+database.QuoteLiteral(want)); n != 1 {
+			t.Errorf("Redo() did not bring back %q", want)
+		}
+	}
+	if _, err := m.Redo(); err == nil {
+		t.Error("Redo() with nothing to redo succeeded")
+	}
+
+	// a new change clears what could be redone
+	if err := m.DeleteRow("notes", cols, []any{int64(1), "final"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EditRow("notes", cols, []any{int64(1), "final"}, []any{int64(1), "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Redo(); err == nil {
+		t.Error("Redo() after a new change succeeded")
+	}
+}
+
+func TestRowsWithoutKey(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE log (level TEXT, msg TEXT)")
+	mustExec(t, m, "INSERT INTO log VALUES ('info', 'a'), ('info', 'b'), ('info', 'b')")
+	mustExec(t, m, "CREATE VIEW infos AS SELECT * FROM log")
+	cols, err := m.GetTableSchema("log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the row with all the values changes, not every row with its first value
+	if err := m.EditRow("log", cols, []any{"info", "a"}, []any{"warn", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.CountWhere("log", "level = 'info'"); n != 2 {
+		t.Errorf("EditRow() left %d info rows, want 2", n)
+	}
+
+	// of two equal rows one is edited and one deleted
+	if err := m.EditRow("log", cols, []any{"info", "b"}, []any{"info", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.CountWhere("log", "msg = 'b'"); n != 1 {
+		t.Errorf("EditRow() of a duplicate left %d rows with b, want 1", n)
+	}
+	if err := m.DeleteRow("log", cols, []any{"info", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := rowCount(t, m, "log"); n != 2 {
+		t.Errorf("DeleteRow() left %d rows, want 2", n)
+	}
+	if err := m.DeleteRow("log", cols, []any{"info", "b"}); err == nil {
+		t.Error("DeleteRow() of a missing row succeeded")
+	}
+
+	// undoing the delete brings the row back even with an equal row there
+	mustExec(t, m, "INSERT INTO log VALUES ('info', 'b')")
+	if _, err := m.Undo(); err != nil {
+		t.Fatalf("Undo() of the delete = %v", err)
+	}
+	if n, _ := m.CountWhere("log", "msg = 'b'"); n != 2 {
+		t.Errorf("Undo() left %d rows with b, want 2", n)
+	}
+
+	if err := m.InsertRow("log", cols, []any{"debug", database.Default}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Undo(); err != nil || rowCount(t, m, "log") != 4 {
+		t.Errorf("Undo() of the insert = %v, %d rows", err, rowCount(t, m, "log"))
+	}
+
+	viewCols, err := m.GetTableSchema("infos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteRow("infos", viewCols, []any{"info", "b"}); err == nil {
+		t.Error("DeleteRow() on a view succeeded")
+	}
+}
+
+// date and time text is written back and matched as stored, not as the
+// driver parses it
+func TestDateColumns(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE ev (id INTEGER PRIMARY KEY, day DATE, note TEXT)")
+	mustExec(t, m, "CREATE TABLE ts (at TIMESTAMP PRIMARY KEY, note TEXT)")
+	mustExec(t, m, "CREATE TABLE days (day DATE, note TEXT)")
+	mustExec(t, m, "INSERT INTO ev VALUES (1, '2024-01-15', 'a')")
+	mustExec(t, m, "INSERT INTO ts VALUES ('2024-01-15T10:00:00Z', 'a')")
+	mustExec(t, m, "INSERT INTO days VALUES ('2024-01-15', 'a'), ('2024-01-16', 'b')")
+
+	load := func(table string) ([]database.Column, []any) {
+		t.Helper()
+		cols, err := m.GetTableSchema(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := m.GetTableData(table, 10, 0)
+		if err != nil || len(rows) == 0 {
+			t.Fatalf("GetTableData(%s) = %v, %v", table, rows, err)
+		}
+		return cols, rows[0]
+	}
+
+	cols, row := load("ev")
+	if row[1] != "2024-01-15" {
+		t.Errorf("GetTableData() day = %#v, want the stored text", row[1])
+	}
+	if err := m.EditRow("ev", cols, row, []any{row[0], row[1], "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.CountWhere("ev", "day = '2024-01-15' AND note = 'b'"); n != 1 {
+		t.Error("EditRow() of note changed day")
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Errorf("Undo() = %v", err)
+	}
+
+	cols, row = load("ts")
+	if err := m.EditRow("ts", cols, row, []any{row[0], "b"}); err != nil {
+		t.Fatalf("EditRow() by TIMESTAMP key = %v", err)
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Errorf("Undo() = %v", err)
+	}
+	if err := m.DeleteRow("ts", cols, row); err != nil {
+		t.Fatalf("DeleteRow() by TIMESTAMP key = %v", err)
+	}
+
+	cols, row = load("days")
+	if err := m.DeleteRow("days", cols, row); err != nil {
+		t.Fatalf("DeleteRow() without a key = %v", err)
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Errorf("Undo() = %v", err)
+	}
+	if n, _ := m.CountWhere("days", "day = '2024-01-15'"); n != 1 {
+		t.Error("Undo() of the delete did not restore the stored day")
+	}
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, `CREATE TABLE items (
+		id INTEGER PRIMARY KEY,
+		price REAL,
+		qty INTEGER,
+		total REAL GENERATED ALWAYS AS (price * qty) VIRTUAL,
+		label TEXT AS (upper(name)) STORED,
+		name TEXT
+	)`)
+
+	cols, err := m.GetTableSchema("items")
+	if err != nil || cols[3].Generated != "VIRTUAL" || cols[4].Generated != "STORED" || cols[3].Writable() {
+		t.Fatalf("GetTableSchema() = %+v, %v", cols, err)
+	}
+
+	// the form passes a value for every column, generated ones included
+	if err := m.InsertRow("items", cols, []any{database.Default, 2.5, int64(4), nil, "old", "pen"}); err != nil {
+		t.Fatalf("InsertRow() = %v", err)
+	}
+	rows, err := m.GetTableData("items", 10, 0)
+	if err != nil || len(rows) != 1 || rows[0][3] != 10.0 || rows[0][4] != "PEN" {
+		t.Fatalf("GetTableData() = %v, %v", rows, err)
+	}
+
+	edited := []any{rows[0][0], 2.5, int64(2), rows[0][3], rows[0][4], "ink"}
+	if err := m.EditRow("items", cols, rows[0], edited); err != nil {
+		t.Fatalf("EditRow() = %v", err)
+	}
+	if n, _ := m.CountWhere("items", "total = 5 AND label = 'INK'"); n != 1 {
+		t.Error("generated columns were not computed after the edit")
+	}
+
+	// undo writes the row images back, generated values included
+	if _, err := m.Undo(); err != nil {
+		t.Fatalf("Undo() = %v", err)
+	}
+	if _, err := m.Redo(); err != nil {
+		t.Fatalf("Redo() = %v", err)
+	}
+	rows, _ = m.GetTableData("items", 10, 0)
+	if err := m.DeleteRow("items", cols, rows[0]); err != nil {
+		t.Fatalf("DeleteRow() = %v", err)
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Fatalf("Undo() of a delete = %v", err)
+	}
+	if n, _ := m.CountWhere("items", "total = 5 AND label = 'INK'"); n != 1 {
+		t.Error("undoing the delete did not restore the row")
+	}
+}
+
+func TestQualifiedNames(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, m, "CREATE INDEX items_name ON items (name)")
+	mustExec(t, m, `CREATE TABLE "main.literal" (a TEXT)`)
+	// a temp table hides the main one for unqualified names
+	mustExec(t, m, "CREATE TEMP TABLE items (id INTEGER PRIMARY KEY, label TEXT, extra TEXT)")
+	mustExec(t, m, "CREATE TEMP TABLE parent (id INTEGER PRIMARY KEY)")
+	mustExec(t, m, "CREATE TEMP TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id))")
+
+	for name, want := range map[string]int{"main.items": 2, "MAIN.items": 2, "temp.items": 3, "items": 3, "main.literal": 1} {
+		cols, err := m.GetTableSchema(name)
+		if err != nil || len(cols) != want {
+			t.Errorf("GetTableSchema(%q) = %d columns, %v, want %d", name, len(cols), err, want)
+		}
+	}
+
+	cols, err := m.GetTableSchema("main.items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.InsertRow("main.items", cols, []any{database.Default, "main row"}); err != nil {
+		t.Fatal(err)
+	}
+	if main, temp := rowCount(t, m, "main.items"), rowCount(t, m, "temp.items"); main != 1 || temp != 0 {
+		t.Errorf("insert into main.items: main has %d rows, temp has %d", main, temp)
+	}
+	rows, err := m.GetTableData("main.items", 10, 0)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("GetTableData(main.items) = %v, %v", rows, err)
+	}
+	if err := m.EditRow("main.items", cols, rows[0], []any{rows[0][0], "edited"}); err != nil {
+		t.Errorf("EditRow(main.items) = %v", err)
+	}
+	if _, err := m.Undo(); err != nil {
+		t.Errorf("Undo() on main.items = %v", err)
+	}
+
+	if info, err := m.GetTableInfo("temp.items"); err != nil || info.ColumnCount != 3 {
+		t.Errorf("GetTableInfo(temp.items) = %+v, %v", info, err)
+	}
+	if indexes, err := m.GetIndexes("main.items"); err != nil || len(indexes) != 1 {
+		t.Errorf("GetIndexes(main.items) = %+v, %v", indexes, err)
+	}
+	if def, err := m.GetTableDefinition("temp.items"); err != nil || !strings.Contains(def.SQL, "extra") {
+		t.Errorf("GetTableDefinition(temp.items) = %+v, %v", def, err)
+	}
+
+	tempCols, err := m.GetTableSchema("temp.items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxCols, _ := database.ParseIndexColumns("label", tempCols)
+	stmt := m.CreateIndexSQL(database.Index{Name: "items_label", Columns: idxCols}, "temp.items")
+	if stmt != `CREATE INDEX "temp"."items_label" ON "items" ("label")` {
+		t.Errorf("CreateIndexSQL(temp.items) = %s", stmt)
+	}
+	mustExec(t, m, stmt)
+	if indexes, err := m.GetIndexes("temp.items"); err != nil || len(indexes) != 1 || indexes[0].Name != "items_label" {
+		t.Errorf("GetIndexes(temp.items) = %+v, %v", indexes, err)
+	}
+
+	create := strings.Replace(m.TriggerTemplate("temp.items"), "BEGIN\n  \nEND", "BEGIN SELECT 1; END", 1)
+	if err := m.SaveTrigger("temp.items", "", create); err != nil {
+		t.Fatalf("SaveTrigger(%s) = %v", create, err)
+	}
+	if triggers, err := m.GetTriggers("temp.items"); err != nil || len(triggers) != 1 {
+		t.Errorf("GetTriggers(temp.items) = %+v, %v", triggers, err)
+	}
+	if triggers, err := m.GetTriggers("main.items"); err != nil || len(triggers) != 0 {
+		t.Errorf("GetTriggers(main.items) = %+v, %v", triggers, err)
+	}
+
+	// the parent of a qualified table is in its schema
+	fks, err := m.GetForeignKeys("temp.child")
+	if err != nil || len(fks) != 1 || fks[0].RefTable != "temp.parent" {
+		t.Errorf("GetForeignKeys(temp.child) = %+v, %v", fks, err)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, m, "INSERT INTO items (name) VALUES ('main')")
+
+	dir := t.TempDir()
+	other, err := database.NewManager(filepath.Join(dir, "other db.db"), database.Options{Create: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, other, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	mustExec(t, other, "INSERT INTO items (name) VALUES ('other'), ('main')")
+	other.Close()
+
+	// files are only created when the main database may be
+	plain, err := database.NewManager(filepath.Join(dir, "other db.db"), database.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Attach(filepath.Join(dir, "missing.db"), ""); err == nil {
+		t.Error("Attach() of a missing file without Create succeeded")
+	}
+	plain.Close()
+
+	schema, err := m.Attach(filepath.Join(dir, "other db.db"), "")
+	if err != nil || schema != "other_db" {
+		t.Fatalf("Attach() = %q, %v", schema, err)
+	}
+	// the same file again gets a new name
+	if again, err := m.Attach(filepath.Join(dir, "other db.db"), ""); err != nil || again != "other_db_2" {
+		t.Errorf("Attach() twice = %q, %v", again, err)
+	}
+	if _, err := m.Attach(filepath.Join(dir, "other db.db"), "other_db"); err == nil {
+		t.Error("Attach() under a taken name succeeded")
+	}
+
+	schemas, err := m.ListSchemas()
+	if err != nil || len(schemas) != 3 || schemas[1].Name != "other_db" || !slices.Equal(schemas[1].Tables, []string{"other_db.items"}) {
+		t.Fatalf("ListSchemas() = %+v, %v", schemas, err)
+	}
+	if n := rowCount(t, m, "other_db.items"); n != 2 {
+		t.Errorf("GetRowCount(other_db.items) = %d", n)
+	}
+	if n := rowCount(t, m, "items"); n != 1 {
+		t.Errorf("GetRowCount(items) = %d, want the main table", n)
+	}
+
+	_, rows, err := m.ExecuteQuery("SELECT o.name FROM other_db.items o JOIN main.items i ON i.name = o.name")
+	if err != nil || len(rows) != 1 {
+		t.Errorf("cross database join = %v, %v", rows, err)
+	}
+
+	if err := m.Detach("other_db_2"); err != nil {
+		t.Errorf("Detach() = %v", err)
+	}
+	if err := m.Detach("main"); err == nil {
+		t.Error("Detach(main) succeeded")
+	}
+
+	// every method works on tables of the attached database
+	mustExec(t, m, "CREATE TABLE other_db.sentinel (id INTEGER)")
+	for _, name := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			mustExec(t, m, "CREATE TABLE other_db."+quote(name)+` (
+				id INTEGER PRIMARY KEY,
+				"full name" TEXT COLLATE NOCASE UNIQUE,
+				parent INTEGER REFERENCES `+quote(name)+`(id)
+			)`)
+			testTable(t, m, "other_db."+name)
+		})
+	}
+	if n := rowCount(t, m, "other_db.sentinel"); n != 0 {
+		t.Errorf("GetRowCount(other_db.sentinel) = %d", n)
+	}
+}
+
+// an index or trigger with the same name in main is left alone when the one
+// of an attached table is dropped or replaced
+func TestAttachedDrops(t *testing.T) {
+	m := newManager(t)
+	path := filepath.Join(t.TempDir(), "prod.db")
+	other, err := database.NewManager(path, database.Options{Create: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, db := range []*database.Manager{m, other} {
+		mustExec(t, db, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+		mustExec(t, db, "CREATE INDEX idx_name ON users (name)")
+		mustExec(t, db, "CREATE TRIGGER trg AFTER INSERT ON users BEGIN SELECT 1; END")
+	}
+	other.Close()
+	if _, err := m.Attach(path, "prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(schema, kind string) int {
+		t.Helper()
+		_, rows, err := m.ExecuteQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s.sqlite_master WHERE type = '%s'", schema, kind))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int(rows[0][0].(int64))
+	}
+
+	replace := "CREATE TRIGGER prod.trg AFTER DELETE ON users BEGIN SELECT 1; END"
+	if err := m.SaveTrigger("prod.users", "trg", replace); err != nil {
+		t.Fatalf("SaveTrigger(prod.users) = %v", err)
+	}
+	if triggers, err := m.GetTriggers("users"); err != nil || len(triggers) != 1 || triggers[0].Event != "INSERT" {
+		t.Errorf("SaveTrigger(prod.users) changed main's trigger: %+v, %v", triggers, err)
+	}
+
+	mustExec(t, m, m.DropIndexSQL("idx_name", "prod.users"))
+	mustExec(t, m, m.DropTriggerSQL("prod.users", "trg"))
+	if count("prod", "index") != 0 || count("prod", "trigger") != 0 {
+		t.Error("the index and trigger of prod.users were not dropped")
+	}
+	if count("main", "index") != 1 || count("main", "trigger") != 1 {
+		t.Error("the index and trigger of main.users were dropped")
+	}
+}
+
+func TestAttachInTransaction(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, "CREATE TABLE items (id INTEGER PRIMARY KEY)")
+	m.SetTxMode(true)
+	mustExec(t, m, "INSERT INTO items DEFAULT VALUES")
+
+	path := filepath.Join(t.TempDir(), "other.db")
+	if _, err := m.Attach(path, ""); err == nil {
+		t.Error("Attach() with an open transaction succeeded")
+	}
+	if err := m.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Attach(path, ""); err != nil {
+		t.Errorf("Attach() after commit = %v", err)
+	}
+}
+
+func TestDiffTables(t *testing.T) {
+	m := newManager(t)
+	if _, err := m.Attach(filepath.Join(t.TempDir(), "other.db"), ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"main.items", "other.items"} {
+		mustExec(t, m, "CREATE TABLE "+table+` (id INTEGER PRIMARY KEY, "item name" TEXT, qty INTEGER, data BLOB)`)
+		mustExec(t, m, "INSERT INTO "+table+` VALUES (1, 'same', 1, X'00'), (2, 'it''s', 2, NULL), (3, 'gone', 3, NULL)`)
+	}
+	mustExec(t, m, "ALTER TABLE other.items ADD COLUMN extra TEXT")
+	mustExec(t, m, `UPDATE other.items SET qty = 5, data = X'01' WHERE id = 2`)
+	mustExec(t, m, "DELETE FROM other.items WHERE id = 3")
+	mustExec(t, m, "INSERT INTO other.items VALUES (4, NULL, 4, NULL, 'x')")
+
+	diff, err := m.DiffTables("items", "other.items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Count(database.DiffChanged) != 1 || diff.Count(database.DiffOnlyLeft) != 1 || diff.Count(database.DiffOnlyRight) != 1 || diff.Same != 1 {
+		t.Errorf("DiffTables() = %+v", diff)
+	}
+	if !slices.Equal(diff.RightColumns, []string{"extra"}) || len(diff.Columns) != 4 {
+		t.Errorf("DiffTables() columns = %+v, only right %q", diff.Columns, diff.RightColumns)
+	}
+	if changed := diff.Rows[0].Changed; !slices.Equal(changed, []bool{false, false, true, true}) {
+		t.Errorf("DiffTables() changed cells = %v", changed)
+	}
+
+	// the left table takes the rows of the right one
+	for _, stmt := range diff.SyncSQL(true) {
+		mustExec(t, m, stmt)
+	}
+	if synced, err := m.DiffTables("items", "other.items"); err != nil || len(synced.Rows) != 0 {
+		t.Errorf("DiffTables() after SyncSQL(true) = %+v, %v", synced, err)
+	}
+	if n := rowCount(t, m, "items"); n != 3 {
+		t.Errorf("items has %d rows after SyncSQL(true)", n)
+	}
+
+	// and gives them back
+	mustExec(t, m, `UPDATE items SET "item name" = NULL WHERE id = 1`)
+	mustExec(t, m, "DELETE FROM other.items WHERE id = 4")
+	diff, err = m.DiffTables("items", "other.items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range diff.SyncSQL(false) {
+		mustExec(t, m, stmt)
+	}
+	if synced, err := m.DiffTables("items", "other.items"); err != nil || len(synced.Rows) != 0 {
+		t.Errorf("DiffTables() after SyncSQL(false) = %+v, %v", synced, err)
+	}
+
+	// column affinity makes INTEGER 1 and TEXT '1' equal in SQL, the types differ
+	mustExec(t, m, "CREATE TABLE main.typed (id INTEGER PRIMARY KEY, v INTEGER)")
+	mustExec(t, m, "CREATE TABLE other.typed (id INTEGER PRIMARY KEY, v TEXT)")
+	mustExec(t, m, "INSERT INTO main.typed VALUES (1, 1), (2, 2)")
+	mustExec(t, m, "INSERT INTO other.typed VALUES (1, '1'), (2, 2)")
+	if diff, err := m.DiffTables("main.typed", "other.typed"); err != nil || diff.Count(database.DiffChanged) != 2 {
+		t.Errorf("DiffTables() of values with other types = %+v, %v", diff, err)
+	}
+
+	mustExec(t, m, "CREATE TABLE other.keyless (name TEXT)")
+	if _, err := m.DiffTables("items", "other.keyless"); err == nil {
+		t.Error("DiffTables() without the key column on the right succeeded")
+	}
+}
+
+func TestSchemaDiff(t *testing.T) {
+	m := newManager(t)
+	if _, err := m.Attach(filepath.Join(t.TempDir(), "target.db"), ""); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, m, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+	CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total REAL, note TEXT);
+	CREATE TABLE old (id INTEGER);
+	CREATE INDEX orders_user ON orders (user_id);
+	CREATE VIEW big AS SELECT * FROM orders WHERE total > 100;
+	CREATE TRIGGER users_del AFTER DELETE ON users BEGIN DELETE FROM orders WHERE user_id = old.id; END;
+	INSERT INTO users VALUES (1, 'ann');
+	INSERT INTO orders VALUES (1, 1, 150, 'x');`)
+	mustExec(t, m, `CREATE TABLE target.users (id INTEGER PRIMARY KEY, name TEXT,
+		email TEXT DEFAULT '');
+	CREATE TABLE target.orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), total REAL);
+	CREATE TABLE target."new table" (id INTEGER PRIMARY KEY);
+	CREATE INDEX target.orders_user ON orders (user_id);
+	CREATE INDEX target.orders_total ON orders (total);
+	CREATE VIEW target.big AS SELECT * FROM orders WHERE total > 100;
+	CREATE TRIGGER target.users_del AFTER DELETE ON users BEGIN DELETE FROM orders WHERE user_id = old.id; END;`)
+
+	diff, err := m.DiffSchemas("main", "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := make(map[string]database.ObjectDiff)
+	for _, o := range diff.Objects {
+		changes[o.Name] = o
+	}
+	if len(changes) != 5 || diff.Same != 3 {
+		t.Errorf("DiffSchemas() = %+v, %d same", diff.Objects, diff.Same)
+	}
+	if o := changes["users"]; o.Change != database.SchemaChanged || o.Rebuild || len(o.Columns) != 1 {
+		t.Errorf("users = %+v, want an added column", o)
+	}
+	if o := changes["orders"]; !o.Rebuild || len(o.Columns) != 2 {
+		t.Errorf("orders = %+v, want a rebuild", o)
+	}
+	if changes["old"].Change != database.SchemaRemoved || changes["new table"].Change != database.SchemaAdded ||
+		changes["orders_total"].Change != database.SchemaAdded {
+		t.Errorf("DiffSchemas() = %+v", diff.Objects)
+	}
+
+	mustExec(t, m, diff.MigrationScript())
+	after, err := m.DiffSchemas("main", "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Objects) != 0 {
+		for _, o := range after.Objects {
+			t.Errorf("%s %s %s after migrating:\n%v\n%v", o.Change, o.Type, o.Name, o.Left, o.Right)
+		}
+	}
+	if n := rowCount(t, m, "orders"); n != 1 {
+		t.Errorf("orders has %d rows after the rebuild", n)
+	}
+	if stmts := after.MigrationSQL(); len(stmts) != 0 {
+		t.Errorf("MigrationSQL() of equal schemas = %q", stmts)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	m := newManager(t)
+	dir := t.TempDir()
+	files := map[string]string{
+		"0001_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);",
+		"0001_users.down.sql": "DROP TABLE users;",
+		"0002_email.up.sql":   "ALTER TABLE users ADD COLUMN email TEXT;\nCREATE INDEX users_email ON users (email);",
+		"0002_email.down.sql": "DROP INDEX users_email;\nALTER TABLE users DROP COLUMN email;",
+		"0003_broken.up.sql":  "CREATE TABLE tags (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);",
+		"notes.txt":           "skipped",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userVersion := func() int {
+		t.Helper()
+		_, rows, err := m.ExecuteQuery("PRAGMA user_version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int(rows[0][0].(int64))
+	}
+
+	migrations, err := m.Migrations(dir)
+	if err != nil || len(migrations) != 3 || migrations[0].String() != "0001_users" || migrations[2].Down != "" {
+		t.Fatalf("Migrations() = %+v, %v", migrations, err)
+	}
+
+	for want := 1; want <= 2; want++ {
+		mig, err := m.MigrateUp(dir)
+		if err != nil || mig.Version != int64(want) {
+			t.Fatalf("MigrateUp() = %+v, %v", mig, err)
+		}
+		if v := userVersion(); v != want {
+			t.Errorf("user_version = %d after %s", v, mig)
+		}
+	}
+
+	// the failing migration leaves no trace
+	if _, err := m.MigrateUp(dir); err == nil {
+		t.Fatal("MigrateUp() of a broken migration succeeded")
+	}
+	if tables, _ := m.ListTables(); slices.Contains(tables, "tags") {
+		t.Error("tags was created by a failed migration")
+	}
+	migrations, _ = m.Migrations(dir)
+	if next, ok := database.NextMigration(migrations); !ok || next.Version != 3 || userVersion() != 2 {
+		t.Errorf("NextMigration() after a failure = %+v, %v", next, ok)
+	}
+
+	for want := 1; want >= 0; want-- {
+		if _, err := m.MigrateDown(dir); err != nil {
+			t.Fatal(err)
+		}
+		if v := userVersion(); v != want {
+			t.Errorf("user_version = %d after rolling back to %d", v, want)
+		}
+	}
+	if _, err := m.MigrateDown(dir); err == nil {
+		t.Error("MigrateDown() with nothing applied succeeded")
+	}
+	if tables, _ := m.ListTables(); slices.Contains(tables, "users") {
+		t.Error("users is left after rolling back every migration")
+	}
+
+	m.SetTxMode(true)
+	mustExec(t, m, "CREATE TABLE pending (id INTEGER)")
+	if _, err := m.MigrateUp(dir); err == nil {
+		t.Error("MigrateUp() with an open transaction succeeded")
+	}
+}
+
+func TestGenerateRows(t *testing.T) {
+	schema := []string{
+		`CREATE TABLE authors (code TEXT PRIMARY KEY, name TEXT NOT NULL, email TEXT NOT NULL UNIQUE)`,
+		`CREATE TABLE books (
+			id INTEGER PRIMARY KEY,
+			author_code TEXT NOT NULL REFERENCES authors (code),
+			title TEXT NOT NULL,
+			genre TEXT NOT NULL CHECK (genre IN ('sf', 'crime', 'poetry')),
+			price DECIMAL(10, 2) NOT NULL,
+			published_on DATE
+		)`,
+		`CREATE TABLE "book tags" (book_id INTEGER NOT NULL REFERENCES books, tag TEXT NOT NULL, PRIMARY KEY (book_id, tag)) WITHOUT ROWID`,
+		`CREATE TABLE staff (id INTEGER PRIMARY KEY, manager_id INTEGER REFERENCES staff (id), username TEXT UNIQUE)`,
+	}
+	generate := func(seed int64) (*database.Manager, []database.Seeded) {
+		t.Helper()
+		m := newManager(t)
+		for _, s := range schema {
+			mustExec(t, m, s)
+		}
+		generated, used, err := m.GenerateRows([]string{"book tags", "staff"}, database.GenerateOptions{Rows: 25, Seed: seed})
+		if err != nil || used != seed {
+			t.Fatalf("GenerateRows() = %v, %d, %v", generated, used, err)
+		}
+		return m, generated
+	}
+
+	m, generated := generate(7)
+	var order []string
+	for _, g := range generated {
+		order = append(order, g.Table)
+		if g.Inserted != 25 || rowCount(t, m, g.Table) != 25 {
+			t.Errorf("%s got %d rows, want 25", g.Table, rowCount(t, m, g.Table))
+		}
+	}
+	// the empty parents of book tags are filled first
+	if want := []string{"authors", "books", "book tags", "staff"}; !slices.Equal(order, want) {
+		t.Errorf("tables filled in order %v, want %v", order, want)
+	}
+
+	checks := map[string]string{
+		"foreign key violations":   "SELECT COUNT(*) FROM pragma_foreign_key_check",
+		"genres outside the CHECK": "SELECT COUNT(*) FROM books WHERE genre NOT IN ('sf', 'crime', 'poetry')",
+		"duplicate emails":         "SELECT COUNT(*) - COUNT(DISTINCT email) FROM authors",
+		"emails without @":         "SELECT COUNT(*) FROM authors WHERE email NOT LIKE '%@%'",
+		"NULL titles":              "SELECT COUNT(*) FROM books WHERE title IS NULL",
+		"prices not numbers":       "SELECT COUNT(*) FROM books WHERE typeof(price) NOT IN ('real', 'integer')",
+		// rows of a table referencing itself point at the ones before them
+		"staff without managers": "SELECT COUNT(*) = 0 FROM staff WHERE manager_id IS NOT NULL",
+	}
+	for what, query := range checks {
+		_, rows, err := m.ExecuteQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := rows[0][0].(int64); n != 0 {
+			t.Errorf("%d %s", n, what)
+		}
+	}
+
+	// the same seed gives the same rows
+	other, _ := generate(7)
+	for _, table := range []string{"authors", "books", "book tags", "staff"} {
+		a, _ := m.GetTableData(table, 100, 0)
+		b, _ := other.GetTableData(table, 100, 0)
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			t.Errorf("%s differs between two runs with the same seed", table)
+		}
+	}
+
+	// a failing table undoes the whole run
+	mustExec(t, m, "CREATE TABLE broken (id INTEGER PRIMARY KEY, n INTEGER CHECK (n < 0))")
+	if _, _, err := m.GenerateRows([]string{"staff", "broken"}, database.GenerateOptions{Rows: 5, Seed: 1}); err == nil {
+		t.Error("GenerateRows() into a table no row fits succeeded")
+	}
+	if n := rowCount(t, m, "staff"); n != 25 {
+		t.Errorf("staff has %d rows after a failed run, want 25", n)
+	}
+}
+
+func TestSeedFixtures(t *testing.T) {
+	m := newManager(t)
+	mustExec(t, m, `CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL, active BOOLEAN, meta TEXT)`)
+	mustExec(t, m, `CREATE TABLE books (isbn TEXT PRIMARY KEY, author_id INTEGER NOT NULL REFERENCES authors (id), title TEXT)`)
+	mustExec(t, m, `CREATE TABLE notes (body TEXT, day TEXT)`)
+	mustExec(t, m, "PRAGMA foreign_keys = ON")
+
+	dir := t.TempDir()
+	files := map[string]string{
+		// books reference authors, a later file, so the order comes from the keys
+		"books.json":  `[{"isbn": "978-0", "author_id": 1, "title": "Notes"}, {"isbn": "978-1", "author_id": 9007199254740993, "title": "Big"}]`,
+		"more.yaml":   "authors:\n  - {id: 1, name: Ada, active: true, meta: {tags: [math]}}\n  - {id: 9007199254740993, name: Big}\nnotes:\n  - body: hello\n    day: 2024-01-15\n",
+		"skipped.txt": "not a fixture",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fixtures, err := database.LoadFixtures(dir)
+	if err != nil || len(fixtures) != 3 {
+		t.Fatalf("LoadFixtures() = %v, %v", fixtures, err)
+	}
+
+	seeded, err := m.SeedFixtures(fixtures, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []database.Seeded{{Table: "authors", Inserted: 2}, {Table: "books", Inserted: 2}, {Table: "notes", Inserted: 1}}
+	if !slices.Equal(seeded, want) {
+		t.Errorf("SeedFixtures() = %v, want %v", seeded, want)
+	}
+	_, rows, _ := m.ExecuteQuery("SELECT active, meta FROM authors WHERE id = 1")
+	if len(rows) != 1 || rows[0][0] != int64(1) || rows[0][1] != `{"tags":["math"]}` {
+		t.Errorf("authors row 1 = %v", rows)
+	}
+	if n, _ := m.CountWhere("books", "author_id = 9007199254740993"); n != 1 {
+		t.Error("a large JSON integer lost precision")
+	}
+	if n, _ := m.CountWhere("notes", "day = '2024-01-15'"); n != 1 {
+		t.Error("a YAML date was not stored as written")
+	}
+
+	// seeding again updates the rows with a key and adds the ones without
+	mustExec(t, m, "UPDATE authors SET name = 'changed' WHERE id = 1")
+	seeded, err = m.SeedFixtures(fixtures, false)
+	want = []database.Seeded{{Table: "authors", Updated: 2}, {Table: "books", Updated: 2}, {Table: "notes", Inserted: 1}}
+	if err != nil || !slices.Equal(seeded, want) {
+		t.Errorf("SeedFixtures() again = %v, %v, want %v", seeded, err, want)
+	}
+	if n, _ := m.CountWhere("authors", "name = 'Ada'"); n != 1 || rowCount(t, m, "authors") != 2 || rowCount(t, m, "notes") != 2 {
+		t.Error("seeding again did not restore the fixture rows")
+	}
+
+	// a row breaking a constraint undoes the whole seed
+	broken := []database.Fixture{
+		{Table: "notes", Rows: []map[string]any{{"body": "kept out"}}},
+		{Table: "books", Rows: []map[string]any{{"isbn": "978-2", "author_id": 404}}},
+	}
+	if _, err := m.SeedFixtures(broken, false); err == nil {
+		t.Error("SeedFixtures() with a missing parent succeeded")
+	}
+	if rowCount(t, m, "notes") != 2 {
+		t.Error("rows of a failed seed were kept")
+	}
+	if _, err := m.SeedFixtures([]database.Fixture{{Table: "notes", Rows: []map[string]any{{"missing": 1}}}}, false); err == nil {
+		t.Error("SeedFixtures() with an unknown column succeeded")
+	}
+
+	script := filepath.Join(dir, "seed.sql")
+	os.WriteFile(script, []byte("INSERT INTO notes (body) VALUES ('a'), ('b');\nCREATE TABLE tags (name TEXT);\nINSERT INTO tags VALUES ('x');"), 0o644)
+	seeded, err = m.SeedScript(script)
+	want = []database.Seeded{{Table: "notes", Inserted: 2}, {Table: "tags", Inserted: 1}}
+	if err != nil || !slices.Equal(seeded, want) {
+		t.Errorf("SeedScript() = %v, %v, want %v", seeded, err, want)
+	}
+}
+
+func TestSeedSafely(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.db")
+	m, err := database.NewManager(path, database.Options{Create: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	opts := database.SeedOptions{Generate: database.GenerateOptions{Rows: 3, Seed: 1}}
+	backups := func() []string {
+		files, _ := filepath.Glob(path + ".*.bak")
+		return files
+	}
+
+	// an empty table of the user named like a demo table is not dropped silently
+	mustExec(t, m, "CREATE TABLE users (id INTEGER PRIMARY KEY, handle TEXT)")
+	if err := m.CheckEmpty(opts); err == nil {
+		t.Error("CheckEmpty() dropping an empty table without a terminal or Yes succeeded")
+	}
+	if cols, _ := m.GetTableSchema("users"); len(cols) != 2 {
+		t.Fatal("a refused seed dropped the empty users table")
+	}
+	mustExec(t, m, "DROP TABLE users")
+
+	// an empty database is seeded without asking
+	if err := m.CheckEmpty(opts); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, m, "UPDATE users SET username = 'kept' WHERE id = 1")
+
+	// dropping tables with rows needs an answer, and there is nobody to ask
+	if err := m.CheckEmpty(opts); err == nil {
+		t.Error("CheckEmpty() without a terminal or Yes succeeded")
+	}
+	dry := opts
+	dry.DryRun, dry.Yes = true, true
+	if err := m.CheckEmpty(dry); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.CountWhere("users", "username = 'kept'"); n != 1 || len(backups()) != 0 {
+		t.Fatal("a refused seed or dry run changed the database")
+	}
+
+	yes := opts
+	yes.Yes = true
+	if err := m.CheckEmpty(yes); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.CountWhere("users", "username = 'kept'"); n != 0 {
+		t.Error("seeding with Yes kept the old rows")
+	}
+	files := backups()
+	if len(files) != 1 {
+		t.Fatalf("backups = %v, want one", files)
+	}
+	backup, err := database.NewManager(files[0], database.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+	if n, _ := backup.CountWhere("users", "username = 'kept'"); n != 1 {
+		t.Error("the backup does not hold the rows before seeding")
+	}
+
+	// only the dropped table is made again, the others keep their rows
+	mustExec(t, m, "UPDATE users SET username = 'kept' WHERE id = 1")
+	mustExec(t, m, "DROP TABLE reviews")
+	missing := opts
+	missing.Missing = true
+	if err := m.CheckEmpty(missing); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.CountWhere("users", "username = 'kept'"); n != 1 || rowCount(t, m, "users") != 3 || rowCount(t, m, "reviews") != 3 {
+		t.Error("seeding missing tables changed existing ones")
+	}
+	if len(backups()) != 1 {
+		t.Error("seeding missing tables made a backup")
+	}
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bookkeeping table of the applied migrations, in the main database
+const migrationsTable = "schema_migrations"
+
+// a numbered migration, from its files and the bookkeeping table
+type Migration struct {
+	Version   int64
+	Name      string
+	Up        string // path of the .up.sql file, empty when it is missing
+	Down      string // path of the .down.sql file, empty when there is none
+	Applied   bool
+	AppliedAt string
+}
+
+// file name of the migration without the direction, ex. 0003_add_tags
+func (mig Migration) String() string {
+	return fmt.Sprintf("%04d_%s", mig.Version, mig.Name)
+}
+
+func (mig Migration) file(up bool) string {
+	if up {
+		return mig.Up
+	}
+	return mig.Down
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// directory migrations are read from when none is given, next to the database
+func (m *Manager) MigrationsDir() string {
+	if m.migrations != "" {
+		return m.migrations
+	}
+	return filepath.Join(filepath.Dir(m.path), "migrations")
+}
+
+// reads the NNNN_name.up.sql and NNNN_name.down.sql files of dir in version order.
+// other files are skipped
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid migration version %s: %w", e.Name(), err)
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("Migrations %d_%s and %s have the same version", version, mig.Name, e.Name())
+		}
+
+		path := filepath.Join(dir, e.Name())
+		if match[3] == "up" {
+			mig.Up = path
+		} else {
+			mig.Down = path
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("Migration %s has no up file", mig)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// the migrations of dir marked with the ones the database has applied. applied
+// versions whose files are gone are listed too, without an Up file
+func (m *Manager) Migrations(dir string) ([]Migration, error) {
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations {
+		if a, ok := applied[migrations[i].Version]; ok {
+			migrations[i].Applied = true
+			migrations[i].AppliedAt = a.AppliedAt
+			delete(applied, migrations[i].Version)
+		}
+	}
+	for _, a := range applied {
+		migrations = append(migrations, a)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// rows of the bookkeeping table by version, none when it does not exist yet
+func (m *Manager) appliedMigrations() (map[int64]Migration, error) {
+	var exists int
+	err := m.conn().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, migrationsTable).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check for %s: %w", migrationsTable, err)
+	}
+	applied := make(map[int64]Migration)
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := m.conn().Query(`SELECT version, name, applied_at FROM ` + migrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		mig := Migration{Applied: true}
+		if err := rows.Scan(&mig.Version, &mig.Name, &mig.AppliedAt); err != nil {
+			return nil, fmt.Errorf("Failed to scan migration: %w", err)
+		}
+		applied[mig.Version] = mig
+	}
+	return applied, rows.Err()
+}
+
+// the migration MigrateUp would apply, false when all are applied
+func NextMigration(migrations []Migration) (Migration, bool) {
+	for _, mig := range migrations {
+		if !mig.Applied {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// the migration MigrateDown would roll back, false when none is applied
+func LastMigration(migrations []Migration) (Migration, bool) {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Applied {
+			return migrations[i], true
+		}
+	}
+	return Migration{}, false
+}
+
+// applies the pending migration of dir with the lowest version
+func (m *Manager) MigrateUp(dir string) (Migration, error) {
+	migrations, err := m.Migrations(dir)
+	if err != nil {
+		return Migration{}, err
+	}
+	mig, ok := NextMigration(migrations)
+	if !ok {
+		return Migration{}, fmt.Errorf("No pending migrations in %s", dir)
+	}
+	return mig, m.migrate(mig, true)
+}
+
+// rolls back the applied migration with the highest version using its down file
+func (m *Manager) MigrateDown(dir string) (Migration, error) {
+	migrations, err := m.Migrations(dir)
+	if err != nil {
+		return Migration{}, err
+	}
+	mig, ok := LastMigration(migrations)
+	if !ok {
+		return Migration{}, fmt.Errorf("No applied migrations to roll back")
+	}
+	return mig, m.migrate(mig, false)
+}
+
+// runs the up or down file of mig and updates the bookkeeping table and
+// user_version, all in one transaction so a failing statement changes nothing
+func (m *Manager) migrate(mig Migration, up bool) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	if m.inTx() {
+		return fmt.Errorf("Commit or roll back the open transaction before running a migration")
+	}
+
+	file := mig.file(up)
+	if file == "" {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		return fmt.Errorf("Migration %s has no %s file", mig, direction)
+	}
+	script, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("Failed to read migration: %w", err)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		return fmt.Errorf("Failed to create %s: %w", migrationsTable, err)
+	}
+
+	if strings.TrimSpace(string(script)) != "" {
+		if _, err := tx.Exec(string(script)); err != nil {
+			return fmt.Errorf("Migration %s failed: %w", filepath.Base(file), err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO `+migrationsTable+` (version, name) VALUES (?, ?)`, mig.Version, mig.Name)
+	} else {
+		_, err = tx.Exec(`DELETE FROM `+migrationsTable+` WHERE version = ?`, mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to record migration %s: %w", mig, err)
+	}
+
+	// user_version holds the latest applied version when it fits its 32 bits
+	var latest int64
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM ` + migrationsTable).Scan(&latest); err != nil {
+		return fmt.Errorf("Failed to read the latest migration: %w", err)
+	}
+	if latest <= math.MaxInt32 {
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", latest)); err != nil {
+			return fmt.Errorf("Failed to set user_version: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Failed to commit migration %s: %w", mig, err)
+	}
+	return nil
+}
+
+// reads the up or down file of a migration, to show it before it runs
+func MigrationSQL(mig Migration, up bool) (string, error) {
+	file := mig.file(up)
+	if file == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read migration: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import "strings"
+
+// a table name resolved to the schema holding it
+type tableRef struct {
+	schema    string // main, temp or an attached database
+	name      string
+	qualified bool // written as schema.table
+}
+
+// finds the schema of a table. schema.table is split when schema is a database
+// on the connection and no table has the whole name, unqualified names are looked
+// up the way SQLite does, temp first. names that match nothing are left in main
+func (m *Manager) resolve(tableName string) tableRef {
+	ref := tableRef{schema: "main", name: tableName}
+
+	err := m.conn().QueryRow(
+		`SELECT t.schema FROM pragma_table_list t JOIN pragma_database_list d ON d.name = t.schema
+		WHERE t.name = ? COLLATE NOCASE
+		ORDER BY d.name != 'temp', d.seq LIMIT 1`,
+		tableName,
+	).Scan(&ref.schema)
+	if err == nil {
+		return ref
+	}
+
+	if i := strings.Index(tableName, "."); i > 0 {
+		var schema string
+		err := m.conn().QueryRow(
+			`SELECT name FROM pragma_database_list WHERE name = ? COLLATE NOCASE`,
+			tableName[:i],
+		).Scan(&schema)
+		if err == nil {
+			return tableRef{schema: schema, name: tableName[i+1:], qualified: true}
+		}
+	}
+	return ref
+}
+
+// the name for use in statements, with its schema if it was written with one
+func (r tableRef) quoted() string {
+	if r.qualified {
+		return quoteIdentifier(r.schema) + "." + quoteIdentifier(r.name)
+	}
+	return quoteIdentifier(r.name)
+}
+
+// the sqlite_master table of the schema
+func (r tableRef) master() string {
+	return quoteIdentifier(r.schema) + ".sqlite_master"
+}
+
+// qualifies a table in the same schema as r, ex. the parent of a foreign key
+func (r tableRef) sibling(name string) string {
+	if r.qualified {
+		return r.schema + "." + name
+	}
+	return name
+}
@@ -2,9 +2,12 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Table metadata
@@ -13,55 +16,47 @@ type TableInfo struct {
 	RowCount    int
 	ColumnCount int
 	Type        string // table or view
+	Size        int64  // bytes used by the table and its indexes, -1 if unknown
 }
 
 // Column metadata (SQLite specific)
 type Column struct {
-	CID          int     // position in table
-	Name         string  // title
-	Type         string  // data type
-	NotNull      bool    // not null constraint
-	DefaultValue *string // nil if none
-	PK           bool    // Primary key
+	CID          int      // position in table
+	Name         string   // title
+	Type         string   // data type
+	NotNull      bool     // not null constraint
+	DefaultValue *string  // nil if none
+	PK           bool     // Primary key
+	Enum         []string // allowed values from CHECK(col IN (...))
+	RefTable     string   // table referenced by a foreign key on this column
+	RefColumn    string   // column referenced in RefTable
+	Collate      string   // collation from the CREATE statement, empty for BINARY
+	Unique       bool     // covered by a single column UNIQUE constraint or index
+	Generated    string   // VIRTUAL or STORED for generated columns
+	Hidden       bool     // hidden column of a virtual table, SELECT * leaves it out
 }
 
 // Returns list of table names
 func (m *Manager) ListTables() ([]string, error) {
-	var tables []string
-
-	query := `SELECT name FROM sqlite_master
-	WHERE type ='table' AND name NOT LIKE 'sqlite_%'
-	ORDER BY name;`
-
-	rows, err := m.db.Query(query)
+	schemas, err := m.ListSchemas()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get table names: %w", err)
-	}
-
-	defer rows.Close()
-
-	for rows.Next() {
-		var name string
-		err := rows.Scan(&name)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to scan table: %w", err)
-		}
-		tables = append(tables, name)
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("Error iterating tables: %w", err)
+	var tables []string
+	for _, s := range schemas {
+		tables = append(tables, s.Tables...)
 	}
-
 	return tables, nil
 }
 
 func (m *Manager) GetTableInfo(tableName string) (*TableInfo, error) {
-	info := &TableInfo{Name: tableName}
+	info := &TableInfo{Name: tableName, Size: -1}
+	ref := m.resolve(tableName)
 
 	var tableType string
-	err := m.db.QueryRow(`SELECT type FROM sqlite_master WHERE name = ?`,
-		tableName).Scan(&tableType)
+	err := m.conn().QueryRow(`SELECT type FROM `+ref.master()+` WHERE name = ? COLLATE NOCASE`,
+		ref.name).Scan(&tableType)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get table type: %w", err)
 	}
@@ -76,8 +71,8 @@ func (m *Manager) GetTableInfo(tableName string) (*TableInfo, error) {
 	// get row count, (doesn't apply to views)
 	if tableType == "table" {
 		var count int
-		query := fmt.Sprintf("Select COUNT(*) FROM %s", quoteIdentifier(tableName))
-		if err := m.db.QueryRow(query).Scan(&count); err != nil {
+		query := fmt.Sprintf("Select COUNT(*) FROM %s", ref.quoted())
+		if err := m.conn().QueryRow(query).Scan(&count); err != nil {
 			return nil, fmt.Errorf("Failed to get row count: %w", err)
 		}
 		info.RowCount = count
@@ -86,13 +81,31 @@ func (m *Manager) GetTableInfo(tableName string) (*TableInfo, error) {
 	return info, nil
 }
 
-// Returns all columns of table
+// Returns all columns of table, the ones SELECT * returns
 func (m *Manager) GetTableSchema(tableName string) ([]Column, error) {
+	all, err := m.tableColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+
 	var cols []Column
+	for _, col := range all {
+		if !col.Hidden {
+			cols = append(cols, col)
+		}
+	}
+	return cols, nil
+}
 
-	query := fmt.Sprintf("PRAGMA table_info(%s)", tableName)
+// every column of the table including hidden ones, from table_xinfo
+func (m *Manager) tableColumns(tableName string) ([]Column, error) {
+	var cols []Column
+	ref := m.resolve(tableName)
 
-	rows, err := m.db.Query(query)
+	rows, err := m.conn().Query(
+		`SELECT cid, name, type, "notnull", dflt_value, pk, hidden FROM pragma_table_xinfo(?, ?)`,
+		ref.name, ref.schema,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("Error getting table info: %w", err)
 	}
@@ -104,6 +117,7 @@ func (m *Manager) GetTableSchema(tableName string) ([]Column, error) {
 		var defaultVal sql.NullString
 		var notNullInt int
 		var pkInt int
+		var hidden int
 
 		err := rows.Scan(
 			&col.CID,
@@ -112,6 +126,7 @@ func (m *Manager) GetTableSchema(tableName string) ([]Column, error) {
 			&notNullInt,
 			&defaultVal,
 			&pkInt,
+			&hidden,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("Error scanning column: %w", err)
@@ -120,6 +135,15 @@ func (m *Manager) GetTableSchema(tableName string) ([]Column, error) {
 		// SQLite bools are ints so we need to convert
 		col.NotNull = notNullInt == 1
 		col.PK = pkInt > 0
+		// 1 is a hidden column of a virtual table, 2 and 3 are generated columns
+		switch hidden {
+		case 1:
+			col.Hidden = true
+		case 2:
+			col.Generated = "VIRTUAL"
+		case 3:
+			col.Generated = "STORED"
+		}
 
 		if defaultVal.Valid {
 			col.DefaultValue = &defaultVal.String
@@ -136,13 +160,52 @@ func (m *Manager) GetTableSchema(tableName string) ([]Column, error) {
 		return nil, fmt.Errorf("Table %s not found or has no columns", tableName)
 	}
 
+	// CHECK constraints and collations are only available in the original CREATE statement
+	var createSQL sql.NullString
+	err = m.conn().QueryRow(`SELECT sql FROM `+ref.master()+` WHERE name = ? COLLATE NOCASE`, ref.name).Scan(&createSQL)
+	if err == nil {
+		enums := parseCheckEnums(createSQL.String)
+		defs := columnDefs(createSQL.String)
+		for i := range cols {
+			cols[i].Enum = enums[strings.ToLower(cols[i].Name)]
+			cols[i].Collate = parseCollate(defs[strings.ToLower(cols[i].Name)])
+		}
+	}
+
+	unique, err := m.uniqueColumns(ref)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cols {
+		cols[i].Unique = unique[strings.ToLower(cols[i].Name)]
+	}
+
+	fks, err := m.GetForeignKeys(tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, fk := range fks {
+		for n, name := range fk.Columns {
+			for i := range cols {
+				if strings.EqualFold(cols[i].Name, name) && n < len(fk.RefColumns) {
+					cols[i].RefTable = fk.RefTable
+					cols[i].RefColumn = fk.RefColumns[n]
+				}
+			}
+		}
+	}
+
 	return cols, nil
 }
 
-func (m *Manager) GetTableData(tableName string, limit, offset int) ([][]string, error) {
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT ? OFFSET ?", quoteIdentifier(tableName))
+func (m *Manager) GetTableData(tableName string, limit, offset int) ([][]any, error) {
+	list, err := m.selectList(tableName)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s LIMIT ? OFFSET ?", list, m.resolve(tableName).quoted())
 
-	rows, err := m.db.Query(query, limit, offset)
+	rows, err := m.conn().Query(query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to query table data: %w", err)
 	}
@@ -168,10 +231,10 @@ func (m *Manager) GetTableData(tableName string, limit, offset int) ([][]string,
 
 // returns total # of rows in a table
 func (m *Manager) GetRowCount(tableName string) (int, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(tableName))
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", m.resolve(tableName).quoted())
 
 	var count int
-	err := m.db.QueryRow(query).Scan(&count)
+	err := m.conn().QueryRow(query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("Failed to get row count: %w", err)
 	}
@@ -180,7 +243,7 @@ func (m *Manager) GetRowCount(tableName string) (int, error) {
 }
 
 // search rows in a table
-func (m *Manager) SearchTable(tableName, term string, limit, offset int) ([][]string, error) {
+func (m *Manager) SearchTable(tableName, term string, limit, offset int) ([][]any, error) {
 	if term == "" {
 		return m.GetTableData(tableName, limit, offset)
 	}
@@ -205,9 +268,15 @@ func (m *Manager) SearchTable(tableName, term string, limit, offset int) ([][]st
 		return m.GetTableData(tableName, limit, offset)
 	}
 
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = rawColumn(quoteIdentifier(col.Name))
+	}
+
 	where := strings.Join(conditions, " OR ")
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT ? OFFSET ?",
-		quoteIdentifier(tableName),
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT ? OFFSET ?",
+		strings.Join(names, ", "),
+		m.resolve(tableName).quoted(),
 		where,
 	)
 
@@ -219,7 +288,7 @@ func (m *Manager) SearchTable(tableName, term string, limit, offset int) ([][]st
 	args[len(conditions)] = limit
 	args[len(conditions)+1] = offset
 
-	rows, err := m.db.Query(query, args...)
+	rows, err := m.conn().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to search table: %w", err)
 	}
@@ -246,25 +315,25 @@ func (m *Manager) GetDBInfo() (map[string]string, error) {
 
 	info["path"] = m.path
 
-	if err := m.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+	if err := m.conn().QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
 		return nil, err
 	}
 	info["page_size"] = fmt.Sprintf("%d bytes", pageSize)
 
-	if err := m.db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+	if err := m.conn().QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
 		return nil, err
 	}
 	info["page_count"] = fmt.Sprintf("%d", pageCount)
 
 	dbSize := pageSize * pageCount
-	info["size"] = formatBytes(dbSize)
+	info["size"] = FormatBytes(dbSize)
 
-	if err := m.db.QueryRow("PRAGMA encoding").Scan(&encoding); err != nil {
+	if err := m.conn().QueryRow("PRAGMA encoding").Scan(&encoding); err != nil {
 		return nil, err
 	}
 	info["encoding"] = encoding
 
-	if err := m.db.QueryRow("PRAGMA foreign_keys").Scan(&fkEnabled); err != nil {
+	if err := m.conn().QueryRow("PRAGMA foreign_keys").Scan(&fkEnabled); err != nil {
 		return nil, err
 	}
 	if fkEnabled == 1 {
@@ -273,22 +342,132 @@ func (m *Manager) GetDBInfo() (map[string]string, error) {
 		info["foreign_keys"] = "disabled"
 	}
 
+	var journalMode, version string
+	var freelist int
+	if err := m.conn().QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		return nil, err
+	}
+	info["journal_mode"] = journalMode
+
+	if err := m.conn().QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+		return nil, err
+	}
+	info["sqlite_version"] = version
+
+	if err := m.conn().QueryRow("PRAGMA freelist_count").Scan(&freelist); err != nil {
+		return nil, err
+	}
+	info["freelist_count"] = fmt.Sprintf("%d (%s)", freelist, FormatBytes(freelist*pageSize))
+
+	var userVersion int
+	if err := m.conn().QueryRow("PRAGMA user_version").Scan(&userVersion); err != nil {
+		return nil, err
+	}
+	info["user_version"] = fmt.Sprintf("%d", userVersion)
+
 	tables, err := m.ListTables()
 	if err != nil {
 		return nil, err
 	}
 	info["table_count"] = fmt.Sprintf("%d", len(tables))
 
+	schemas, err := m.ListSchemas()
+	if err != nil {
+		return nil, err
+	}
+	var attached []string
+	for _, s := range schemas {
+		if s.Name != "main" && s.Name != "temp" {
+			attached = append(attached, fmt.Sprintf("%s (%s)", s.Name, s.File))
+		}
+	}
+	info["attached"] = "none"
+	if len(attached) > 0 {
+		info["attached"] = strings.Join(attached, ", ")
+	}
+
 	return info, nil
 }
 
+// options SQLite was compiled with, ex. ENABLE_FTS5
+func (m *Manager) GetCompileOptions() ([]string, error) {
+	rows, err := m.conn().Query("PRAGMA compile_options")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get compile options: %w", err)
+	}
+	defer rows.Close()
+
+	var opts []string
+	for rows.Next() {
+		var opt string
+		if err := rows.Scan(&opt); err != nil {
+			return nil, fmt.Errorf("Failed to scan compile option: %w", err)
+		}
+		opts = append(opts, opt)
+	}
+	return opts, rows.Err()
+}
+
+// bytes used by each table and its indexes from the dbstat virtual table.
+// returns nil without an error if SQLite was built without dbstat
+func (m *Manager) GetTableSizes() (map[string]int64, error) {
+	schemas, err := m.ListSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64)
+	for _, s := range schemas {
+		err := m.schemaSizes(s.Name, sizes)
+		if err != nil && strings.Contains(err.Error(), "no such table: dbstat") {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get table sizes: %w", err)
+		}
+	}
+	return sizes, nil
+}
+
+// adds the sizes of the tables of one schema, keyed like ListTables
+func (m *Manager) schemaSizes(schema string, sizes map[string]int64) error {
+	rows, err := m.conn().Query(`SELECT m.tbl_name, SUM(s.pgsize)
+	FROM dbstat(?) s JOIN `+quoteIdentifier(schema)+`.sqlite_master m ON m.name = s.name
+	GROUP BY m.tbl_name`, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var size int64
+		if err := rows.Scan(&name, &size); err != nil {
+			return fmt.Errorf("Failed to scan table size: %w", err)
+		}
+		if schema != "main" {
+			name = schema + "." + name
+		}
+		sizes[name] = size
+	}
+	return rows.Err()
+}
+
 // execs a custom sql query and returns the results
-func (m *Manager) ExecuteQuery(query string) ([]string, [][]string, error) {
+func (m *Manager) ExecuteQuery(query string) ([]string, [][]any, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return nil, nil, fmt.Errorf("Error empty query")
 	}
 
+	if m.readOnly && isWriteStatement(query) {
+		return nil, nil, fmt.Errorf("%w: only SELECT, PRAGMA and EXPLAIN statements can be run", ErrReadOnly)
+	}
+
+	if m.TxMode() && isTxStatement(query) {
+		return nil, nil, fmt.Errorf("Transaction mode is on, use the commit/rollback keys instead")
+	}
+
 	qUpper := strings.ToUpper(query)
 	isSelect := strings.HasPrefix(qUpper, "SELECT") ||
 	strings.HasPrefix(qUpper, "PRAGMA") ||
@@ -296,24 +475,26 @@ func (m *Manager) ExecuteQuery(query string) ([]string, [][]string, error) {
 
 	if !isSelect {
 		// insert, update, delete...
-		res, err := m.db.Exec(query)
+		q, err := m.writer()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res, err := q.Exec(query)
 		if err != nil {
 			return nil, nil, fmt.Errorf("Failed to execute query: %w", err)
 		}
+		m.record(Change{Kind: ChangeStatement, SQL: query})
 		rowsAffected, _ := res.RowsAffected()
 		lastInsertId, _ := res.LastInsertId()
 
 		cols := []string{"Result", "Rows Affected", "Last Insert ID"}
-		row := []string{
-			"Success",
-			fmt.Sprintf("%d", rowsAffected),
-			fmt.Sprintf("%d", lastInsertId),
-		}
-		return cols, [][]string{row}, nil
+		row := []any{"Success", rowsAffected, lastInsertId}
+		return cols, [][]any{row}, nil
 	}
 
 	// select
-	rows, err := m.db.Query(query)
+	rows, err := m.conn().Query(query)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Failed to execute query: %w", err)
 	}
@@ -332,25 +513,62 @@ func (m *Manager) ExecuteQuery(query string) ([]string, [][]string, error) {
 	return cols, res, nil
 }
 
-func (m *Manager) EditRow(tableName, id string, columns []Column, row []string) error {
-	query := "UPDATE %s SET %s WHERE id = ?"
+// updates a row, old is the row as it was loaded and is used to find it by key
+func (m *Manager) EditRow(tableName string, columns []Column, old, row []any) error {
+	q, err := m.writer()
+	if err != nil {
+		return err
+	}
 
-	parts := make([]string, len(columns))
-	args := make([]any, len(columns) + 1)
+	keys := keyIndexes(columns)
+	oldVals := dbValues(old)
+	newVals := dbValues(row)
 
-	for i, col := range columns {
-		parts[i] = fmt.Sprintf("%s = ?", quoteIdentifier(col.Name))
-		args[i] = stringToValue(col.Type, row[i])
+	table := m.resolve(tableName).quoted()
+	where, whereArgs, err := rowWhere(q, table, columns, keys, oldVals)
+	if err != nil {
+		return err
+	}
+	before, err := fetchRow(q, table, columns, where, whereArgs)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("No rows affected")
 	}
 
-	args[len(columns)] = id
+	// only the changed columns are written, the others keep what is stored
+	var parts []string
+	var args []any
+	writable := false
+	for i, col := range columns {
+		if !col.Writable() {
+			continue
+		}
+		writable = true
+		if sameStored(oldVals[i], newVals[i]) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s = ?", quoteIdentifier(col.Name)))
+		args = append(args, newVals[i])
+	}
+	if !writable {
+		return fmt.Errorf("%s has no columns that can be edited", tableName)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
 
-	query = fmt.Sprintf(
-		query, quoteIdentifier(tableName),
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s",
+		table,
 		strings.Join(parts, ", "),
+		where,
 	)
 
-	res, err := m.db.Exec(query, args...)
+	args = append(args, whereArgs...)
+
+	res, err := q.Exec(query, args...)
 	if err != nil {
 		return err
 	}
@@ -363,12 +581,235 @@ func (m *Manager) EditRow(tableName, id string, columns []Column, row []string)
 	if rowsAffected == 0 {
 		return fmt.Errorf("No rows affected")
 	}
-	
+
+	// a row found by rowid keeps it
+	if len(keys) > 0 {
+		where, whereArgs = keyWhere(columns, keys, newVals)
+	}
+	after, err := fetchRow(q, table, columns, where, whereArgs)
+	if err != nil || after == nil {
+		after = newVals
+	}
+
+	m.record(Change{
+		Kind:    ChangeUpdate,
+		Table:   tableName,
+		Columns: columns,
+		Key:     keyString(columns, keys, oldVals),
+		Before:  before,
+		After:   after,
+	})
+
 	return nil
 }
 
-func extractRows(rows *sql.Rows, cols []string) ([][]string, error) {
-	var res [][]string
+// inserts a new row, Default values are left out so column defaults apply
+func (m *Manager) InsertRow(tableName string, columns []Column, row []any) error {
+	q, err := m.writer()
+	if err != nil {
+		return err
+	}
+
+	table := m.resolve(tableName).quoted()
+	var names, marks []string
+	var args []any
+	for i, col := range columns {
+		if row[i] == Default || !col.Writable() {
+			continue
+		}
+		names = append(names, quoteIdentifier(col.Name))
+		marks = append(marks, "?")
+		args = append(args, dbValue(row[i]))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table)
+	if len(names) > 0 {
+		query = fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES (%s)",
+			table,
+			strings.Join(names, ", "),
+			strings.Join(marks, ", "),
+		)
+	}
+
+	res, err := q.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("Failed to insert row: %w", err)
+	}
+
+	// find the new row by its key, or rowid when the key was generated or
+	// there is none
+	keys := keyIndexes(columns)
+	vals := dbValues(row)
+	where, whereArgs := keyWhere(columns, keys, vals)
+	if len(keys) == 0 || slices.ContainsFunc(keys, func(k int) bool { return row[k] == Default }) {
+		id, _ := res.LastInsertId()
+		where, whereArgs = "rowid = ?", []any{id}
+	}
+
+	after, err := fetchRow(q, table, columns, where, whereArgs)
+	if err != nil || after == nil {
+		after = vals
+	}
+
+	m.record(Change{
+		Kind:    ChangeInsert,
+		Table:   tableName,
+		Columns: columns,
+		Key:     keyString(columns, keys, after),
+		After:   after,
+	})
+
+	return nil
+}
+
+// deletes the row matching the key of row
+func (m *Manager) DeleteRow(tableName string, columns []Column, row []any) error {
+	q, err := m.writer()
+	if err != nil {
+		return err
+	}
+
+	keys := keyIndexes(columns)
+	vals := dbValues(row)
+
+	table := m.resolve(tableName).quoted()
+	where, whereArgs, err := rowWhere(q, table, columns, keys, vals)
+	if err != nil {
+		return err
+	}
+	before, err := fetchRow(q, table, columns, where, whereArgs)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("No rows affected")
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", table, where)
+	if _, err := q.Exec(query, whereArgs...); err != nil {
+		return fmt.Errorf("Failed to delete row: %w", err)
+	}
+
+	m.record(Change{
+		Kind:    ChangeDelete,
+		Table:   tableName,
+		Columns: columns,
+		Key:     keyString(columns, keys, vals),
+		Before:  before,
+	})
+
+	return nil
+}
+
+// indexes of the primary key columns, none if the table has no primary key
+func keyIndexes(columns []Column) []int {
+	var keys []int
+	for i, col := range columns {
+		if col.PK {
+			keys = append(keys, i)
+		}
+	}
+	return keys
+}
+
+// WHERE clause finding one row by its primary key. a table without one is
+// searched for a row with all the values and that row is then found by its
+// rowid, so of several equal rows only one is written. the clause matches
+// nothing if no row has the values
+func rowWhere(q querier, table string, columns []Column, keys []int, row []any) (string, []any, error) {
+	if len(keys) > 0 {
+		where, args := keyWhere(columns, keys, row)
+		return where, args, nil
+	}
+
+	all := make([]int, len(columns))
+	for i := range all {
+		all[i] = i
+	}
+	where, args := keyWhere(columns, all, row)
+
+	var id int64
+	err := q.QueryRow(fmt.Sprintf("SELECT rowid FROM %s WHERE %s LIMIT 1", table, where), args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return where, args, nil
+	}
+	if err != nil {
+		// views and virtual tables without rowids
+		return "", nil, fmt.Errorf("Rows of %s can't be told apart, it has no primary key or rowid: %w", table, err)
+	}
+	return "rowid = ?", []any{id}, nil
+}
+
+// builds a WHERE clause matching the key columns of a row
+func keyWhere(columns []Column, keys []int, row []any) (string, []any) {
+	var parts []string
+	var args []any
+	for _, k := range keys {
+		if row[k] == nil {
+			parts = append(parts, fmt.Sprintf("%s IS NULL", quoteIdentifier(columns[k].Name)))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s = ?", quoteIdentifier(columns[k].Name)))
+		args = append(args, row[k])
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// readable key, ex. id=3. the whole row without a primary key
+func keyString(columns []Column, keys []int, row []any) string {
+	if len(keys) == 0 {
+		keys = make([]int, len(columns))
+		for i := range keys {
+			keys[i] = i
+		}
+	}
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%s", columns[k].Name, FormatValue(row[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// loads the raw values of a single row, nil if no row matches. table is quoted
+func fetchRow(q querier, table string, columns []Column, where string, args []any) ([]any, error) {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = rawColumn(quoteIdentifier(col.Name))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s LIMIT 1",
+		strings.Join(names, ", "),
+		table,
+		where,
+	)
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load row: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, fmt.Errorf("Failed to scan row: %w", err)
+	}
+
+	return values, nil
+}
+
+func extractRows(rows *sql.Rows, cols []string) ([][]any, error) {
+	var res [][]any
 	for rows.Next() {
 		values := make([]any, len(cols))
 		valuePtrs := make([]any, len(cols))
@@ -380,12 +821,7 @@ func extractRows(rows *sql.Rows, cols []string) ([][]string, error) {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		row := make([]string, len(cols))
-		for i, val := range values {
-			row[i] = valToString(val)
-		}
-
-		res = append(res, row)
+		res = append(res, values)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -395,12 +831,125 @@ func extractRows(rows *sql.Rows, cols []string) ([][]string, error) {
 	return res, nil
 }
 
+// reports whether a statement could modify the database
+func isWriteStatement(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch fields[0] {
+	case "SELECT", "EXPLAIN", "VALUES":
+		return false
+	case "PRAGMA":
+		// assignments like PRAGMA foreign_keys = ON change state
+		return strings.Contains(query, "=")
+	case "WITH":
+		// CTEs can prefix INSERT/UPDATE/DELETE statements
+		for _, f := range fields[1:] {
+			switch f {
+			case "INSERT", "UPDATE", "DELETE", "REPLACE":
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
 // replaces any quotes in the name with double quotes (SQLite escape)
 func quoteIdentifier(name string) string {
 	e := strings.ReplaceAll(name, `"`, `""`)
 	return fmt.Sprintf(`"%s"`, e)
 }
 
+// selects a column as it is stored. the driver turns the text of DATE,
+// DATETIME and TIMESTAMP columns into time.Time, which would be written back
+// in another format. unary + keeps the value but drops the declared type
+func rawColumn(expr string) string {
+	return "+" + expr
+}
+
+// the columns SELECT * returns, read as they are stored
+func (m *Manager) selectList(tableName string) (string, error) {
+	ref := m.resolve(tableName)
+	rows, err := m.conn().Query(`SELECT name FROM pragma_table_xinfo(?, ?) WHERE hidden != 1`, ref.name, ref.schema)
+	if err != nil {
+		return "", fmt.Errorf("Error getting table info: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", fmt.Errorf("Error scanning column: %w", err)
+		}
+		names = append(names, rawColumn(quoteIdentifier(name)))
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("Error iterating column rows: %w", err)
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("Table %s not found or has no columns", tableName)
+	}
+	return strings.Join(names, ", "), nil
+}
+
+// quotes a string as a SQL literal
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// writes a value as a SQL literal for statements shown to the user
+func SQLLiteral(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case []byte:
+		return fmt.Sprintf("X'%x'", v)
+	default:
+		return QuoteLiteral(valToString(v))
+	}
+}
+
+// how NULL is shown in tables, distinct from the text 'NULL'
+const NullDisplay = "∅"
+
+// marks a column that should use its default value in InsertRow
+var Default any = defaultValue{}
+
+type defaultValue struct{}
+
+// formats a value for display in a table
+func FormatValue(val any) string {
+	if val == nil {
+		return NullDisplay
+	}
+	return valToString(val)
+}
+
+// formats a value for editing, NULL has no text form and is tracked separately
+func EditValue(val any) string {
+	if val == nil {
+		return ""
+	}
+	if f, ok := val.(float64); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return valToString(val)
+}
+
 // convert db vals to strings
 func valToString(val any) string {
 	if val == nil {
@@ -424,17 +973,44 @@ func valToString(val any) string {
 			return "1"
 		}
 		return "0"
+	case time.Time: // the driver parses DATE/DATETIME/TIMESTAMP text
+		return formatTime(v)
 	default: // shouldn't reach
 		return fmt.Sprintf("%v", v)
 	}
 }
 
-func stringToValue(colType string, value string) any {
-	// Handle NULL
-	if value == "" || value == "NULL" {
-		return nil
+// formats times the way SQLite's datetime() does
+func formatTime(t time.Time) string {
+	layout := "2006-01-02 15:04:05"
+	if t.Nanosecond() != 0 {
+		layout += ".999999999"
 	}
-	
+	if t.Location() != time.UTC {
+		layout += "-07:00"
+	}
+	return t.Format(layout)
+}
+
+// converts a scanned value back to something that is written the same way it was read
+func dbValue(val any) any {
+	if t, ok := val.(time.Time); ok {
+		return formatTime(t)
+	}
+	return val
+}
+
+func dbValues(row []any) []any {
+	vals := make([]any, len(row))
+	for i, v := range row {
+		vals[i] = dbValue(v)
+	}
+	return vals
+}
+
+// converts edited text to a value for the column type.
+// NULL is never produced here, "" and "NULL" are stored as text
+func ParseValue(colType string, value string) any {
 	typeUpper := strings.ToUpper(colType)
 	
 	// INTEGER types
@@ -468,8 +1044,8 @@ func stringToValue(colType string, value string) any {
 	return value
 }
 
-// formats bytes into readable format
-func formatBytes(bytes int) string {
+// formats a size in bytes, ex. 512 B or 1.5 MB
+func FormatBytes(bytes int) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -0,0 +1,474 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type SchemaChange int
+
+const (
+	SchemaAdded   SchemaChange = iota // only in the right schema
+	SchemaRemoved                     // only in the left schema
+	SchemaChanged
+)
+
+func (c SchemaChange) String() string {
+	switch c {
+	case SchemaAdded:
+		return "added"
+	case SchemaRemoved:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+// a table, index, trigger or view from sqlite_master
+type SchemaObject struct {
+	Type  string
+	Name  string
+	Table string // table an index or trigger belongs to, the name itself for tables
+	SQL   string
+}
+
+// a column of a changed table, by the text of its definition
+type ColumnDiff struct {
+	Name   string
+	Change SchemaChange
+	Left   string // definition, empty when added
+	Right  string // empty when removed
+}
+
+// an object that differs between the two schemas
+type ObjectDiff struct {
+	Type    string
+	Name    string
+	Change  SchemaChange
+	Left    *SchemaObject // nil when added
+	Right   *SchemaObject // nil when removed
+	Columns []ColumnDiff  // changed tables only
+	Rebuild bool          // the change needs the table to be copied into a new one
+}
+
+// what changes to turn the left schema into the right one
+type SchemaDiff struct {
+	Left    string // schema names, ex. main and an attached database
+	Right   string
+	Objects []ObjectDiff // tables, indexes, views then triggers, by name
+	Same    int          // objects defined the same way in both
+	left    []SchemaObject
+	right   []SchemaObject
+}
+
+// compares the tables, indexes, triggers and views of two databases on the connection
+func (m *Manager) DiffSchemas(left, right string) (*SchemaDiff, error) {
+	leftObjects, err := m.schemaObjects(left)
+	if err != nil {
+		return nil, err
+	}
+	rightObjects, err := m.schemaObjects(right)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &SchemaDiff{Left: left, Right: right, left: leftObjects, right: rightObjects}
+	inRight := make(map[string]*SchemaObject, len(rightObjects))
+	for i := range rightObjects {
+		inRight[objectKey(rightObjects[i])] = &rightObjects[i]
+	}
+	inLeft := make(map[string]bool, len(leftObjects))
+
+	for i := range leftObjects {
+		l := &leftObjects[i]
+		inLeft[objectKey(*l)] = true
+		r := inRight[objectKey(*l)]
+		switch {
+		case r == nil:
+			d.Objects = append(d.Objects, ObjectDiff{Type: l.Type, Name: l.Name, Change: SchemaRemoved, Left: l})
+		case sameDefinition(*l, *r):
+			d.Same++
+		default:
+			o := ObjectDiff{Type: l.Type, Name: l.Name, Change: SchemaChanged, Left: l, Right: r}
+			if l.Type == "table" {
+				o.Columns = diffColumns(l.SQL, r.SQL)
+				_, ok := addColumnSQL(*l, *r)
+				o.Rebuild = !ok
+			}
+			d.Objects = append(d.Objects, o)
+		}
+	}
+	for i := range rightObjects {
+		r := &rightObjects[i]
+		if !inLeft[objectKey(*r)] {
+			d.Objects = append(d.Objects, ObjectDiff{Type: r.Type, Name: r.Name, Change: SchemaAdded, Right: r})
+		}
+	}
+
+	// keep the order of schemaObjects with the added objects among the others
+	sorted := make([]ObjectDiff, 0, len(d.Objects))
+	for _, t := range objectTypes {
+		for _, o := range d.Objects {
+			if o.Type == t {
+				sorted = append(sorted, o)
+			}
+		}
+	}
+	d.Objects = sorted
+	return d, nil
+}
+
+var objectTypes = []string{"table", "index", "view", "trigger"}
+
+// the objects of a schema with a CREATE statement, internal and shadow tables left out
+func (m *Manager) schemaObjects(schema string) ([]SchemaObject, error) {
+	rows, err := m.conn().Query(`SELECT type, name, tbl_name, sql FROM `+quoteIdentifier(schema)+`.sqlite_master
+	WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+	AND name NOT IN (SELECT name FROM pragma_table_list WHERE schema = ? AND type = 'shadow')
+	ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, name`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the schema of %s: %w", schema, err)
+	}
+	defer rows.Close()
+
+	var objects []SchemaObject
+	for rows.Next() {
+		var o SchemaObject
+		if err := rows.Scan(&o.Type, &o.Name, &o.Table, &o.SQL); err != nil {
+			return nil, fmt.Errorf("Failed to scan schema object: %w", err)
+		}
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+func objectKey(o SchemaObject) string {
+	return o.Type + " " + strings.ToLower(o.Name)
+}
+
+var spacePattern = regexp.MustCompile(`\s*([(),])\s*|\s+`)
+
+// collapses whitespace so layout differences do not count as changes
+func normalizeSQL(s string) string {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ";")
+	return spacePattern.ReplaceAllStringFunc(s, func(m string) string {
+		if t := strings.TrimSpace(m); t != "" {
+			return t
+		}
+		return " "
+	})
+}
+
+// true if two objects of the same name are created the same way. the name of a
+// table is left out, ALTER TABLE RENAME writes it quoted
+func sameDefinition(a, b SchemaObject) bool {
+	if a.Type == "table" && !isVirtual(a.SQL) && !isVirtual(b.SQL) {
+		i, j := strings.Index(a.SQL, "("), strings.Index(b.SQL, "(")
+		if i >= 0 && j >= 0 {
+			return normalizeSQL(a.SQL[i:]) == normalizeSQL(b.SQL[j:])
+		}
+	}
+	return normalizeSQL(a.SQL) == normalizeSQL(b.SQL)
+}
+
+// a column definition or table constraint of a CREATE TABLE statement
+type tablePart struct {
+	name   string // lower case column name, empty for constraints
+	def    string // normalized text
+	column string // column name as written
+}
+
+// splits a CREATE TABLE statement into its columns and constraints in order,
+// and the options after the closing parenthesis
+func tableParts(createSQL string) ([]tablePart, string) {
+	start := strings.Index(createSQL, "(")
+	end := strings.LastIndex(createSQL, ")")
+	if start < 0 || end < start {
+		return nil, ""
+	}
+
+	var parts []tablePart
+	for _, def := range splitTopLevel(createSQL[start+1:]) {
+		p := tablePart{def: normalizeSQL(def)}
+		name, _, quoted := splitName(def)
+		if name != "" && (quoted || !tableConstraints[strings.ToUpper(name)]) {
+			p.name = strings.ToLower(name)
+			p.column = name
+		}
+		parts = append(parts, p)
+	}
+	return parts, normalizeSQL(createSQL[end+1:])
+}
+
+// compares the column definitions of two CREATE TABLE statements
+func diffColumns(leftSQL, rightSQL string) []ColumnDiff {
+	leftParts, _ := tableParts(leftSQL)
+	rightParts, _ := tableParts(rightSQL)
+
+	right := make(map[string]tablePart)
+	for _, p := range rightParts {
+		if p.name != "" {
+			right[p.name] = p
+		}
+	}
+
+	var diffs []ColumnDiff
+	left := make(map[string]bool)
+	for _, l := range leftParts {
+		if l.name == "" {
+			continue
+		}
+		left[l.name] = true
+		r, ok := right[l.name]
+		switch {
+		case !ok:
+			diffs = append(diffs, ColumnDiff{l.column, SchemaRemoved, l.def, ""})
+		case r.def != l.def:
+			diffs = append(diffs, ColumnDiff{l.column, SchemaChanged, l.def, r.def})
+		}
+	}
+	for _, r := range rightParts {
+		if r.name != "" && !left[r.name] {
+			diffs = append(diffs, ColumnDiff{r.column, SchemaAdded, "", r.def})
+		}
+	}
+	return diffs
+}
+
+// ALTER TABLE ADD COLUMN can not add these
+var notAddable = regexp.MustCompile(`(?i)\bPRIMARY\s+KEY\b|\bUNIQUE\b|\bSTORED\b|\bCURRENT_(TIME|DATE|TIMESTAMP)\b|\bDEFAULT\s*\(`)
+
+// the ADD COLUMN statements turning left into right, when right only has new
+// columns at the end that ALTER TABLE can add. false if the table must be rebuilt
+func addColumnSQL(left, right SchemaObject) ([]string, bool) {
+	if isVirtual(left.SQL) || isVirtual(right.SQL) {
+		return nil, false
+	}
+
+	leftParts, leftTail := tableParts(left.SQL)
+	rightParts, rightTail := tableParts(right.SQL)
+	if leftTail != rightTail || len(rightParts) <= len(leftParts) {
+		return nil, false
+	}
+	for i, p := range leftParts {
+		if rightParts[i].def != p.def {
+			return nil, false
+		}
+	}
+
+	var stmts []string
+	for _, p := range rightParts[len(leftParts):] {
+		upper := strings.ToUpper(p.def)
+		if p.name == "" || notAddable.MatchString(p.def) ||
+			strings.Contains(upper, "NOT NULL") && !strings.Contains(upper, "DEFAULT") ||
+			strings.Contains(upper, "REFERENCES") && strings.Contains(upper, "DEFAULT") && !strings.Contains(upper, "DEFAULT NULL") {
+			return nil, false
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quoteIdentifier(left.Name), p.def))
+	}
+	return stmts, true
+}
+
+var virtualPattern = regexp.MustCompile(`(?i)^\s*CREATE\s+VIRTUAL\b`)
+
+func isVirtual(createSQL string) bool {
+	return virtualPattern.MatchString(createSQL)
+}
+
+var generatedPattern = regexp.MustCompile(`(?i)\bAS\s*\(`)
+
+// creates the right table under a new name, copies the columns both have, and
+// puts it in place of the left one. a virtual table is dropped and created again
+func rebuildSQL(left, right SchemaObject) []string {
+	name := quoteIdentifier(left.Name)
+	if isVirtual(left.SQL) || isVirtual(right.SQL) {
+		return []string{"DROP TABLE " + name, right.SQL}
+	}
+
+	temp := quoteIdentifier("_dbtui_new_" + left.Name)
+	create := "CREATE TABLE " + temp + " " + right.SQL[strings.Index(right.SQL, "("):]
+
+	leftParts, _ := tableParts(left.SQL)
+	inLeft := make(map[string]bool)
+	for _, p := range leftParts {
+		if p.name != "" && !generatedPattern.MatchString(p.def) {
+			inLeft[p.name] = true
+		}
+	}
+	rightParts, _ := tableParts(right.SQL)
+	var common []string
+	for _, p := range rightParts {
+		if p.name != "" && inLeft[p.name] && !generatedPattern.MatchString(p.def) {
+			common = append(common, quoteIdentifier(p.column))
+		}
+	}
+
+	stmts := []string{create}
+	if len(common) > 0 {
+		cols := strings.Join(common, ", ")
+		stmts = append(stmts, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", temp, cols, cols, name))
+	}
+	return append(stmts,
+		"DROP TABLE "+name,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", temp, name),
+	)
+}
+
+// true if the SQL names the table, roughly: as a word outside of other identifiers
+func mentions(sql, table string) bool {
+	pattern := `(?i)(^|[^\w$])` + regexp.QuoteMeta(table) + `([^\w$]|$)`
+	return regexp.MustCompile(pattern).MatchString(sql)
+}
+
+// the statements turning the left schema into the right one. columns ALTER TABLE
+// can not change are handled by copying the table into a new one, the way the
+// SQLite docs describe, so foreign keys must be off while they run
+func (d *SchemaDiff) MigrationSQL() []string {
+	gone := make(map[string]bool) // tables dropped or rebuilt
+	for _, o := range d.Objects {
+		if o.Type == "table" && (o.Change == SchemaRemoved || o.Rebuild) {
+			gone[strings.ToLower(o.Name)] = true
+		}
+	}
+	dependsOnGone := func(o *SchemaObject) bool {
+		if gone[strings.ToLower(o.Table)] {
+			return true
+		}
+		for t := range gone {
+			if mentions(o.SQL, t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var drops, tables, creates []string
+	recreate := make(map[string]bool) // right views and triggers created again at the end
+
+	// views and triggers go first, they would break while their tables are replaced
+	for _, t := range []string{"view", "trigger"} {
+		for _, o := range d.leftObjects(t) {
+			r := d.rightObject(o)
+			if r != nil && sameDefinition(*o, *r) && !dependsOnGone(o) {
+				continue
+			}
+			drops = append(drops, fmt.Sprintf("DROP %s IF EXISTS %s", strings.ToUpper(t), quoteIdentifier(o.Name)))
+			if r != nil {
+				recreate[objectKey(*r)] = true
+			}
+		}
+	}
+
+	for _, o := range d.Objects {
+		switch {
+		case o.Type == "index" && o.Change != SchemaAdded:
+			drops = append(drops, "DROP INDEX IF EXISTS "+quoteIdentifier(o.Name))
+		case o.Type != "table":
+		case o.Change == SchemaRemoved:
+			drops = append(drops, "DROP TABLE "+quoteIdentifier(o.Name))
+		case o.Change == SchemaAdded:
+			tables = append(tables, o.Right.SQL)
+		case o.Rebuild:
+			tables = append(tables, rebuildSQL(*o.Left, *o.Right)...)
+		default:
+			stmts, _ := addColumnSQL(*o.Left, *o.Right)
+			tables = append(tables, stmts...)
+		}
+	}
+
+	// indexes, views and triggers that are new, changed or lost with a rebuilt table
+	for _, t := range []string{"index", "view", "trigger"} {
+		for _, o := range d.rightObjects(t) {
+			l := d.leftObject(o)
+			switch {
+			case l == nil, !sameDefinition(*l, *o), recreate[objectKey(*o)]:
+			case t != "view" && gone[strings.ToLower(o.Table)]:
+			default:
+				continue
+			}
+			creates = append(creates, o.SQL)
+		}
+	}
+
+	return append(append(drops, tables...), creates...)
+}
+
+// the whole migration with comments and a transaction, as run by the sqlite3 shell
+func (d *SchemaDiff) MigrationScript() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- migrates the schema of %s to the schema of %s\n", d.Left, d.Right)
+
+	stmts := d.MigrationSQL()
+	if len(stmts) == 0 {
+		b.WriteString("-- the schemas are the same\n")
+		return b.String()
+	}
+
+	rebuild := false
+	for _, o := range d.Objects {
+		fmt.Fprintf(&b, "-- %s %s %s", o.Change, o.Type, o.Name)
+		if o.Rebuild {
+			rebuild = true
+			b.WriteString(" (rebuilt, columns the new table has are copied over)")
+		}
+		b.WriteString("\n")
+		for _, c := range o.Columns {
+			fmt.Fprintf(&b, "--   %s column %s\n", c.Change, c.Name)
+		}
+	}
+
+	b.WriteString("\n")
+	if rebuild {
+		b.WriteString("PRAGMA foreign_keys = OFF;\n")
+	}
+	b.WriteString("BEGIN;\n")
+	for _, s := range stmts {
+		b.WriteString(s + ";\n")
+	}
+	if rebuild {
+		b.WriteString("PRAGMA foreign_key_check;\n")
+	}
+	b.WriteString("COMMIT;\n")
+	if rebuild {
+		b.WriteString("PRAGMA foreign_keys = ON;\n")
+	}
+	return b.String()
+}
+
+// objects of a type in the left schema, changed or not, in name order
+func (d *SchemaDiff) leftObjects(objectType string) []*SchemaObject {
+	return ofType(d.left, objectType)
+}
+
+func (d *SchemaDiff) rightObjects(objectType string) []*SchemaObject {
+	return ofType(d.right, objectType)
+}
+
+func ofType(objects []SchemaObject, objectType string) []*SchemaObject {
+	var found []*SchemaObject
+	for i := range objects {
+		if objects[i].Type == objectType {
+			found = append(found, &objects[i])
+		}
+	}
+	return found
+}
+
+// the object of the same type and name on the other side, nil when there is none
+func (d *SchemaDiff) leftObject(o *SchemaObject) *SchemaObject {
+	return find(d.left, objectKey(*o))
+}
+
+func (d *SchemaDiff) rightObject(o *SchemaObject) *SchemaObject {
+	return find(d.right, objectKey(*o))
+}
+
+func find(objects []SchemaObject, key string) *SchemaObject {
+	for i := range objects {
+		if objectKey(objects[i]) == key {
+			return &objects[i]
+		}
+	}
+	return nil
+}
@@ -4,44 +4,281 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
-func (m *Manager) CheckEmpty() error {
-	tables, err := m.ListTables()
+// tables of the demo schema, parents first
+var demoTables = []string{"users", "products", "categories", "orders", "order_items", "reviews"}
+
+// where -seed takes its rows from and whether it keeps what is there
+type SeedOptions struct {
+	// SQL script, or a JSON/YAML fixture file or directory of them. the demo
+	// tables with generated rows if empty
+	Path string
+	// keep existing tables and rows. the demo tables are only created if
+	// missing and fixtures are added to the rows already there
+	Append bool
+	// only create and fill the demo tables that do not exist yet
+	Missing  bool
+	Generate GenerateOptions // rows of the demo tables
+	// print what seeding would do and change nothing
+	DryRun bool
+	// drop and empty tables without asking
+	Yes bool
+	// the user can be asked, otherwise seeding that loses tables or rows needs Yes
+	Interactive bool
+}
+
+// rows a seed added to a table, and rows it replaced because their key was taken
+type Seeded struct {
+	Table    string
+	Inserted int
+	Updated  int
+}
+
+// seeds the database. when seeding drops or empties tables that have rows
+// it asks first, unless opts.Yes, and backs the file up
+func (m *Manager) CheckEmpty(opts SeedOptions) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	if opts.Missing && (opts.Path != "" || opts.Append) {
+		return fmt.Errorf("Only the demo tables can be seeded into missing tables")
+	}
+
+	var fixtures []Fixture
+	script := strings.EqualFold(filepath.Ext(opts.Path), ".sql")
+	if opts.Path != "" && !script {
+		var err error
+		if fixtures, err = LoadFixtures(opts.Path); err != nil {
+			return err
+		}
+	}
+
+	plan, err := m.seedPlan(opts, fixtures)
 	if err != nil {
 		return err
 	}
 
-	if len(tables) > 0 {
-		prompt, err := promptOverwrite()
+	if opts.Missing && len(plan.create) == 0 {
+		fmt.Println("The demo tables exist, nothing to seed.")
+		return nil
+	}
+
+	if opts.DryRun {
+		fmt.Println("Dry run, nothing is changed. Seeding would:")
+		plan.print()
+		return nil
+	}
+
+	if len(plan.lost) > 0 {
+		if !opts.Yes {
+			fmt.Println("Seeding will:")
+			for _, step := range plan.lost {
+				fmt.Println("  " + step)
+			}
+			if !opts.Interactive {
+				return fmt.Errorf("Seeding would drop or empty existing tables, pass -yes to seed without asking")
+			}
+			prompt, err := promptOverwrite()
+			if err != nil {
+				return err
+			}
+			if !prompt {
+				return nil
+			}
+		}
+
+		backup, err := m.backup()
 		if err != nil {
 			return err
 		}
-		if !prompt {
-			return nil
+		if backup != "" {
+			fmt.Printf("Backed up %s to %s\n", m.path, backup)
 		}
 	}
 
-	fmt.Println("Seeding database with test data...")
-
-	err = m.execSeed()
+	var seeded []Seeded
+	var seed int64
+	switch {
+	case opts.Path == "":
+		fmt.Println("Seeding database with test data...")
+		seeded, seed, err = m.execSeed(opts, plan.create)
+	case script:
+		fmt.Printf("Seeding database from %s...\n", opts.Path)
+		seeded, err = m.SeedScript(opts.Path)
+	default:
+		fmt.Printf("Seeding database from %s...\n", opts.Path)
+		seeded, err = m.SeedFixtures(fixtures, !opts.Append)
+	}
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Database successfully seeded.")
+	printSeeded(seeded)
+	if seed != 0 {
+		printSeed(seed)
+	}
+	return nil
+}
+
+// adds generated rows to existing tables, nothing is dropped
+func (m *Manager) SeedTables(tables []string, opts SeedOptions) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+
+	if opts.DryRun {
+		order, err := m.generationOrder(tables)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Dry run, nothing is changed. Seeding would:")
+		for _, t := range order {
+			fmt.Printf("  generate %d row(s) in %s\n", opts.Generate.Rows, t)
+		}
+		return nil
+	}
 
+	seeded, seed, err := m.GenerateRows(tables, opts.Generate)
+	if err != nil {
+		return err
+	}
+
+	printSeeded(seeded)
+	printSeed(seed)
 	return nil
 }
 
-func promptOverwrite() (bool, error) {
-	warning := `
-Warning: This database already contains tables.
-Seeding will overwrite certain tables and data.
-Do you want to continue? (y/N): `
+// what seeding does, in order
+type seedPlan struct {
+	steps  []string
+	lost   []string // the steps dropping tables or emptying rows already there
+	create []string // demo tables created
+}
+
+func (p *seedPlan) add(step string, lost bool) {
+	p.steps = append(p.steps, step)
+	if lost {
+		p.lost = append(p.lost, step)
+	}
+}
+
+func (p *seedPlan) print() {
+	for _, step := range p.steps {
+		fmt.Println("  " + step)
+	}
+}
 
-	fmt.Print(warning)
+// works out what the seed drops, empties, creates and fills, without
+// changing anything
+func (m *Manager) seedPlan(opts SeedOptions, fixtures []Fixture) (*seedPlan, error) {
+	counts, err := m.tableCounts()
+	if err != nil {
+		return nil, err
+	}
+	// table names are case insensitive
+	rows := make(map[string]int, len(counts))
+	for t, n := range counts {
+		rows[strings.ToLower(t)] = n
+	}
+	exists := func(t string) bool {
+		_, ok := rows[strings.ToLower(t)]
+		return ok
+	}
+
+	plan := &seedPlan{}
+	switch {
+	case opts.Path == "":
+		var fill []string
+		for _, t := range demoTables {
+			switch {
+			case !exists(t):
+				plan.create = append(plan.create, t)
+				fill = append(fill, t)
+			case opts.Missing:
+			case opts.Append:
+				fill = append(fill, t)
+			default:
+				// an empty table still holds the user's schema
+				plan.add(fmt.Sprintf("drop %s (%d row(s))", t, rows[t]), true)
+				plan.create = append(plan.create, t)
+				fill = append(fill, t)
+			}
+		}
+		for _, t := range plan.create {
+			plan.add("create "+t, false)
+		}
+		for _, t := range fill {
+			plan.add(fmt.Sprintf("generate %d row(s) in %s", opts.Generate.Rows, t), false)
+		}
+	case len(fixtures) == 0:
+		// a script can change any table, so it loses rows unless appending
+		plan.add(fmt.Sprintf("run %s in one transaction", opts.Path), len(counts) > 0 && !opts.Append)
+	default:
+		// a dry run reports fixtures that do not fit their tables too
+		if _, err := m.fixtureTables(fixtures); err != nil {
+			return nil, err
+		}
+		var tables []string
+		count := make(map[string]int)
+		for _, f := range fixtures {
+			if count[strings.ToLower(f.Table)] == 0 {
+				tables = append(tables, f.Table)
+			}
+			count[strings.ToLower(f.Table)] += len(f.Rows)
+		}
+		if !opts.Append {
+			for _, t := range tables {
+				if n := rows[strings.ToLower(t)]; n > 0 {
+					plan.add(fmt.Sprintf("empty %s (%d row(s))", t, n), true)
+				}
+			}
+		}
+		for _, t := range tables {
+			plan.add(fmt.Sprintf("upsert %d row(s) into %s", count[strings.ToLower(t)], t), false)
+		}
+	}
+	return plan, nil
+}
+
+// copies the database next to it before seeding drops tables or rows, ex.
+// shop.db.20260102-150405.bak. empty if there is no file to copy
+func (m *Manager) backup() (string, error) {
+	if m.path == ":memory:" {
+		return "", nil
+	}
+	path := m.path + "." + time.Now().Format("20060102-150405") + ".bak"
+	// unlike copying the file this includes what is still in the WAL
+	if _, err := m.db.Exec("VACUUM INTO ?", path); err != nil {
+		return "", fmt.Errorf("Failed to back up database: %w", err)
+	}
+	return path, nil
+}
+
+// rows inserted and updated by table, and the total
+func printSeeded(seeded []Seeded) {
+	total := 0
+	for _, s := range seeded {
+		line := fmt.Sprintf("  %-20s %d inserted", s.Table, s.Inserted)
+		if s.Updated > 0 {
+			line += fmt.Sprintf(", %d updated", s.Updated)
+		}
+		fmt.Println(line)
+		total += s.Inserted + s.Updated
+	}
+	fmt.Printf("Seeded %d row(s) in %d table(s).\n", total, len(seeded))
+}
+
+// the seed that generates the same rows again
+func printSeed(seed int64) {
+	fmt.Printf("Seed %d, pass -seed-value %d to generate the same rows\n", seed, seed)
+}
+
+func promptOverwrite() (bool, error) {
+	fmt.Print("Do you want to continue? (y/N): ")
 
 	reader := bufio.NewReader(os.Stdin)
 	res, err := reader.ReadString('\n')
@@ -58,8 +295,11 @@ Do you want to continue? (y/N): `
 	return true, nil
 }
 
-func (m *Manager) execSeed() error {
-	query := `PRAGMA foreign_keys = OFF;
+// drops and creates the demo tables, then fills them. appending keeps the
+// tables and their rows and only creates the missing ones, and missing
+// only fills the tables it created
+func (m *Manager) execSeed(opts SeedOptions, created []string) ([]Seeded, int64, error) {
+	drop := `PRAGMA foreign_keys = OFF;
 
 DROP TABLE IF EXISTS order_items;
 DROP TABLE IF EXISTS reviews;
@@ -69,8 +309,9 @@ DROP TABLE IF EXISTS products;
 DROP TABLE IF EXISTS users;
 
 PRAGMA foreign_keys = ON;
+`
 
-CREATE TABLE users (
+	query := `CREATE TABLE users (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     username TEXT NOT NULL UNIQUE,
     email TEXT NOT NULL UNIQUE,
@@ -80,18 +321,6 @@ CREATE TABLE users (
     is_active BOOLEAN DEFAULT 1
 );
 
-INSERT INTO users (username, email, full_name, age, is_active) VALUES
-    ('jdoe', 'john.doe@example.com', 'John Doe', 28, 1),
-    ('asmith', 'alice.smith@example.com', 'Alice Smith', 34, 1),
-    ('bwilliams', 'bob.williams@example.com', 'Bob Williams', 45, 1),
-    ('cjohnson', 'carol.johnson@example.com', 'Carol Johnson', 29, 0),
-    ('dmiller', 'david.miller@example.com', 'David Miller', 52, 1),
-    ('ebrown', 'emma.brown@example.com', 'Emma Brown', 23, 1),
-    ('fdavis', 'frank.davis@example.com', 'Frank Davis', 41, 1),
-    ('gwilson', 'grace.wilson@example.com', 'Grace Wilson', 36, 0),
-    ('hmoore', 'henry.moore@example.com', 'Henry Moore', 31, 1),
-    ('itaylor', 'iris.taylor@example.com', 'Iris Taylor', 27, 1);
-
 CREATE TABLE products (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
@@ -102,23 +331,6 @@ CREATE TABLE products (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
-INSERT INTO products (name, category, price, stock_quantity, description) VALUES
-    ('Laptop Pro 15', 'Electronics', 1299.99, 45, 'High-performance laptop with 16GB RAM'),
-    ('Wireless Mouse', 'Electronics', 29.99, 230, 'Ergonomic wireless mouse with USB receiver'),
-    ('Office Chair', 'Furniture', 249.50, 67, 'Comfortable ergonomic office chair'),
-    ('Standing Desk', 'Furniture', 499.99, 23, 'Adjustable height standing desk'),
-    ('USB-C Cable', 'Electronics', 12.99, 450, 'Durable USB-C charging cable'),
-    ('Desk Lamp', 'Furniture', 39.99, 120, 'LED desk lamp with adjustable brightness'),
-    ('Mechanical Keyboard', 'Electronics', 89.99, 88, 'RGB mechanical keyboard with blue switches'),
-    ('Monitor 27"', 'Electronics', 349.99, 34, '4K UHD monitor with HDR support'),
-    ('Bookshelf', 'Furniture', 129.99, 41, 'Five-tier wooden bookshelf'),
-    ('Webcam HD', 'Electronics', 79.99, 95, '1080p webcam with built-in microphone'),
-    ('Coffee Maker', 'Appliances', 69.99, 52, 'Programmable coffee maker with thermal carafe'),
-    ('Blender', 'Appliances', 54.99, 38, 'High-speed blender for smoothies'),
-    ('Microwave', 'Appliances', 129.99, 28, 'Compact microwave with 900W power'),
-    ('Headphones', 'Electronics', 159.99, 102, 'Noise-cancelling wireless headphones'),
-    ('Backpack', 'Accessories', 49.99, 145, 'Water-resistant laptop backpack');
-
 CREATE TABLE orders (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     user_id INTEGER NOT NULL,
@@ -129,18 +341,6 @@ CREATE TABLE orders (
     FOREIGN KEY (user_id) REFERENCES users(id)
 );
 
-INSERT INTO orders (user_id, order_date, total_amount, status, shipping_address) VALUES
-    (1, '2024-10-15 10:23:45', 1329.98, 'delivered', '123 Main St, Springfield, IL 62701'),
-    (2, '2024-10-18 14:32:11', 249.50, 'delivered', '456 Oak Ave, Portland, OR 97201'),
-    (3, '2024-10-20 09:15:33', 89.99, 'shipped', '789 Pine Rd, Austin, TX 78701'),
-    (1, '2024-10-22 16:44:22', 79.99, 'processing', '123 Main St, Springfield, IL 62701'),
-    (4, '2024-10-25 11:05:44', 499.99, 'pending', '321 Elm St, Seattle, WA 98101'),
-    (5, '2024-10-27 13:28:56', 159.99, 'delivered', '654 Maple Dr, Boston, MA 02101'),
-    (6, '2024-10-29 08:42:18', 549.98, 'shipped', '987 Cedar Ln, Denver, CO 80201'),
-    (2, '2024-11-01 10:11:33', 349.99, 'processing', '456 Oak Ave, Portland, OR 97201'),
-    (7, '2024-11-02 15:55:27', 129.99, 'pending', '147 Birch St, Miami, FL 33101'),
-    (9, '2024-11-04 12:33:44', 219.97, 'delivered', '258 Willow Way, Phoenix, AZ 85001');
-
 CREATE TABLE order_items (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     order_id INTEGER NOT NULL,
@@ -151,22 +351,6 @@ CREATE TABLE order_items (
     FOREIGN KEY (product_id) REFERENCES products(id)
 );
 
-INSERT INTO order_items (order_id, product_id, quantity, price_at_purchase) VALUES
-    (1, 1, 1, 1299.99),
-    (1, 2, 1, 29.99),
-    (2, 3, 1, 249.50),
-    (3, 7, 1, 89.99),
-    (4, 10, 1, 79.99),
-    (5, 4, 1, 499.99),
-    (6, 14, 1, 159.99),
-    (7, 8, 1, 349.99),
-    (7, 5, 1, 12.99),
-    (7, 6, 5, 39.99),
-    (8, 8, 1, 349.99),
-    (9, 13, 1, 129.99),
-    (10, 15, 2, 49.99),
-    (10, 11, 2, 69.99);
-
 CREATE TABLE reviews (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     product_id INTEGER NOT NULL,
@@ -179,17 +363,6 @@ CREATE TABLE reviews (
     FOREIGN KEY (user_id) REFERENCES users(id)
 );
 
-INSERT INTO reviews (product_id, user_id, rating, comment, review_date, helpful_count) VALUES
-    (1, 1, 5, 'Excellent laptop! Fast and reliable.', '2024-10-20 14:22:33', 12),
-    (1, 5, 4, 'Great performance but a bit pricey.', '2024-10-28 09:45:11', 8),
-    (2, 2, 5, 'Perfect mouse, very comfortable.', '2024-10-19 16:33:22', 5),
-    (3, 2, 4, 'Comfortable chair, good back support.', '2024-10-23 11:18:44', 15),
-    (7, 3, 5, 'Love this keyboard! Keys feel amazing.', '2024-10-25 13:42:55', 22),
-    (8, 2, 5, 'Crystal clear display, worth every penny.', '2024-11-03 10:25:18', 9),
-    (10, 1, 3, 'Decent webcam but average mic quality.', '2024-10-26 15:11:33', 4),
-    (14, 5, 5, 'Best headphones I have ever owned!', '2024-11-01 12:44:22', 18),
-    (15, 9, 4, 'Good backpack with lots of compartments.', '2024-11-05 09:33:11', 6);
-
 CREATE TABLE categories (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,
@@ -197,20 +370,23 @@ CREATE TABLE categories (
     parent_category_id INTEGER,
     FOREIGN KEY (parent_category_id) REFERENCES categories(id)
 );
-
-INSERT INTO categories (name, description, parent_category_id) VALUES
-    ('Electronics', 'Electronic devices and accessories', NULL),
-    ('Furniture', 'Home and office furniture', NULL),
-    ('Appliances', 'Home appliances', NULL),
-    ('Accessories', 'Various accessories', NULL),
-    ('Computers', 'Computer hardware', 1),
-    ('Audio', 'Audio equipment', 1),
-    ('Office', 'Office furniture', 2);
 `
 
+	if opts.Append || opts.Missing {
+		query = strings.ReplaceAll(query, "CREATE TABLE ", "CREATE TABLE IF NOT EXISTS ")
+	} else {
+		query = drop + query
+	}
+
 	if _, err := m.db.Exec(query); err != nil {
-		return err
+		return nil, 0, fmt.Errorf("Failed to create the demo tables: %w", err)
 	}
 
-	return nil
+	fill := demoTables
+	if opts.Missing {
+		if fill = created; len(fill) == 0 {
+			return nil, 0, nil
+		}
+	}
+	return m.GenerateRows(fill, opts.Generate)
 }
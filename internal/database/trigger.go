@@ -0,0 +1,158 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// a trigger from sqlite_master, timing and event are read from its SQL
+type Trigger struct {
+	Name   string
+	Table  string
+	Timing string // BEFORE, AFTER or INSTEAD OF
+	Event  string // INSERT, DELETE, UPDATE or UPDATE OF columns
+	SQL    string
+}
+
+// a trigger name, quoted or bare, optionally with its schema
+const namePattern = `(?:"(?:[^"]|"")*"|\[[^\]]*\]|` + "`[^`]*`" + `|[^\s.]+)`
+
+var (
+	triggerHeadPattern = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:TEMP(?:ORARY)?\s+)?TRIGGER\s+(?:IF\s+NOT\s+EXISTS\s+)?` +
+		namePattern + `(?:\s*\.\s*` + namePattern + `)?\s+(BEFORE\s+|AFTER\s+|INSTEAD\s+OF\s+)?(DELETE|INSERT|UPDATE(?:\s+OF\s+.+?)?)\s+ON\s`)
+	triggerBodyPattern = regexp.MustCompile(`(?is)\bBEGIN\b(.*)\bEND\s*;?\s*$`)
+)
+
+// statements between BEGIN and END
+func (t Trigger) Body() string {
+	if match := triggerBodyPattern.FindStringSubmatch(t.SQL); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return ""
+}
+
+// Returns the triggers defined on a table, including temporary ones
+func (m *Manager) GetTriggers(tableName string) ([]Trigger, error) {
+	ref := m.resolve(tableName)
+	query := `SELECT name, tbl_name, sql FROM ` + ref.master() + `
+		WHERE type = 'trigger' AND tbl_name = ?1 COLLATE NOCASE`
+	// temp triggers name the table without its schema, they are on a temp table if there is one
+	if ref.schema != "temp" {
+		query += `
+		UNION ALL
+		SELECT name, tbl_name, sql FROM sqlite_temp_master
+		WHERE type = 'trigger' AND tbl_name = ?1 COLLATE NOCASE
+		AND NOT EXISTS (SELECT 1 FROM sqlite_temp_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE)`
+	}
+	rows, err := m.conn().Query(query+" ORDER BY name", ref.name)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var triggers []Trigger
+	for rows.Next() {
+		var t Trigger
+		var stmt sql.NullString
+		if err := rows.Scan(&t.Name, &t.Table, &stmt); err != nil {
+			return nil, fmt.Errorf("Failed to scan trigger: %w", err)
+		}
+		t.SQL = stmt.String
+		t.Timing = "BEFORE"
+		if match := triggerHeadPattern.FindStringSubmatch(t.SQL); match != nil {
+			if timing := strings.Join(strings.Fields(match[1]), " "); timing != "" {
+				t.Timing = strings.ToUpper(timing)
+			}
+			// keywords in upper case, the UPDATE OF columns as written
+			event := strings.Fields(match[2])
+			for i := 0; i < min(len(event), 2); i++ {
+				event[i] = strings.ToUpper(event[i])
+			}
+			t.Event = strings.Join(event, " ")
+		}
+		triggers = append(triggers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error iterating triggers: %w", err)
+	}
+	return triggers, nil
+}
+
+// true if the statement creates a trigger
+func IsCreateTrigger(stmt string) bool {
+	fields := strings.Fields(strings.ToUpper(stmt))
+	if len(fields) > 2 && (fields[1] == "TEMP" || fields[1] == "TEMPORARY") {
+		fields = append(fields[:1], fields[2:]...)
+	}
+	return len(fields) > 2 && fields[0] == "CREATE" && fields[1] == "TRIGGER"
+}
+
+// starting point for a new trigger on the table, the schema of a qualified
+// table goes on the trigger name
+func (m *Manager) TriggerTemplate(tableName string) string {
+	ref := m.resolve(tableName)
+	name := quoteIdentifier("trg_" + ref.name)
+	if ref.qualified {
+		name = quoteIdentifier(ref.schema) + "." + name
+	}
+	return fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT ON %s\nBEGIN\n  \nEND", name, quoteIdentifier(ref.name))
+}
+
+// builds a DROP TRIGGER statement for a trigger on the table. the trigger is
+// named with its schema, a bare name is looked up in temp and main first
+func (m *Manager) DropTriggerSQL(tableName, triggerName string) string {
+	return "DROP TRIGGER " + quoteIdentifier(m.triggerSchema(tableName, triggerName)) + "." + quoteIdentifier(triggerName)
+}
+
+// the schema holding a trigger on the table, the table's own or temp
+func (m *Manager) triggerSchema(tableName, triggerName string) string {
+	ref := m.resolve(tableName)
+	for _, schema := range []string{ref.schema, "temp"} {
+		var n int
+		err := m.conn().QueryRow(
+			`SELECT COUNT(*) FROM `+quoteIdentifier(schema)+`.sqlite_master WHERE type = 'trigger' AND name = ? COLLATE NOCASE`,
+			triggerName,
+		).Scan(&n)
+		if err == nil && n > 0 {
+			return schema
+		}
+	}
+	return ref.schema
+}
+
+// statements run by SaveTrigger
+func (m *Manager) SaveTriggerSQL(tableName, oldName, stmt string) []string {
+	stmt = strings.TrimSpace(stmt)
+	if oldName == "" {
+		return []string{stmt}
+	}
+	return []string{m.DropTriggerSQL(tableName, oldName), stmt}
+}
+
+// creates a trigger on the table, dropping oldName first when it is replaced.
+// both statements run in one transaction so a failing CREATE keeps the old trigger
+func (m *Manager) SaveTrigger(tableName, oldName, stmt string) error {
+	if !IsCreateTrigger(stmt) {
+		return fmt.Errorf("Expected a CREATE TRIGGER statement")
+	}
+
+	stmts := m.SaveTriggerSQL(tableName, oldName, stmt)
+	err := m.atomic("save_trigger", func(q querier) error {
+		for _, s := range stmts {
+			if _, err := q.Exec(s); err != nil {
+				return fmt.Errorf("Failed to save trigger: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stmts {
+		m.record(Change{Kind: ChangeStatement, SQL: s})
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// statements run through the transaction when one is open
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type ChangeKind int
+
+const (
+	ChangeUpdate ChangeKind = iota
+	ChangeInsert
+	ChangeDelete
+	ChangeStatement
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeUpdate:
+		return "UPDATE"
+	case ChangeInsert:
+		return "INSERT"
+	case ChangeDelete:
+		return "DELETE"
+	default:
+		return "SQL"
+	}
+}
+
+// a single write made through the UI
+type Change struct {
+	Kind    ChangeKind
+	Table   string
+	Columns []Column
+	Key     string // human readable row key, ex. id=3
+	Before  []any  // row image before the change, nil for inserts
+	After   []any  // row image after the change, nil for deletes
+	SQL     string // statement text for ChangeStatement
+}
+
+// one line of the pending changes diff
+type CellDiff struct {
+	Column string
+	Old    string
+	New    string
+}
+
+// returns the columns that differ between the before and after images
+func (c Change) Diff() []CellDiff {
+	var diffs []CellDiff
+
+	switch c.Kind {
+	case ChangeStatement:
+		return []CellDiff{{New: c.SQL}}
+	case ChangeInsert:
+		for i, col := range c.Columns {
+			diffs = append(diffs, CellDiff{col.Name, "", FormatValue(c.After[i])})
+		}
+	case ChangeDelete:
+		for i, col := range c.Columns {
+			diffs = append(diffs, CellDiff{col.Name, FormatValue(c.Before[i]), ""})
+		}
+	case ChangeUpdate:
+		for i, col := range c.Columns {
+			oldVal, newVal := FormatValue(c.Before[i]), FormatValue(c.After[i])
+			if oldVal != newVal {
+				diffs = append(diffs, CellDiff{col.Name, oldVal, newVal})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// enables transaction mode, writes are held until Commit
+func (m *Manager) SetTxMode(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txMode = enabled
+}
+
+func (m *Manager) TxMode() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.txMode
+}
+
+// returns a copy of the uncommitted changes
+func (m *Manager) Pending() []Change {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Change(nil), m.pending...)
+}
+
+func (m *Manager) HasPending() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending) > 0
+}
+
+func (m *Manager) Commit() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tx == nil {
+		return nil
+	}
+
+	err := m.tx.Commit()
+	m.tx = nil
+	m.pending = nil
+	if err != nil {
+		return fmt.Errorf("Failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) Rollback() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tx == nil {
+		return nil
+	}
+
+	err := m.tx.Rollback()
+	m.tx = nil
+	m.pending = nil
+
+	// the rows these refer to no longer exist in that state
+	m.undo = nil
+	m.redo = nil
+	if err != nil {
+		return fmt.Errorf("Failed to rollback transaction: %w", err)
+	}
+	return nil
+}
+
+// returns the open transaction or the db for reads
+func (m *Manager) conn() querier {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tx != nil {
+		return m.tx
+	}
+	return m.db
+}
+
+// returns a handle for writes, opening a transaction in transaction mode
+func (m *Manager) writer() (querier, error) {
+	if m.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.txMode {
+		return m.db, nil
+	}
+
+	if m.tx == nil {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to begin transaction: %w", err)
+		}
+		m.tx = tx
+	}
+	return m.tx, nil
+}
+
+// runs fn in a transaction of its own, or in a savepoint when the managed
+// transaction is open, so a failing fn undoes only what it wrote
+func (m *Manager) atomic(name string, fn func(q querier) error) error {
+	q, err := m.writer()
+	if err != nil {
+		return err
+	}
+
+	db, ok := q.(*sql.DB)
+	if !ok {
+		if _, err := q.Exec("SAVEPOINT " + name); err != nil {
+			return fmt.Errorf("Failed to create savepoint: %w", err)
+		}
+		if err := fn(q); err != nil {
+			q.Exec("ROLLBACK TO " + name)
+			q.Exec("RELEASE " + name)
+			return err
+		}
+		if _, err := q.Exec("RELEASE " + name); err != nil {
+			return fmt.Errorf("Failed to release savepoint: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("Failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// keeps track of a change made through the UI so it can be reviewed and undone
+func (m *Manager) record(c Change) {
+	m.addPending(c)
+
+	if c.Kind == ChangeStatement {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.undo = append(m.undo, c)
+	m.redo = nil
+}
+
+func (m *Manager) addPending(c Change) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.txMode {
+		m.pending = append(m.pending, c)
+	}
+}
+
+// transaction control statements would break the managed transaction
+func isTxStatement(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.TrimSuffix(fields[0], ";") {
+	case "BEGIN", "COMMIT", "END", "ROLLBACK":
+		return true
+	}
+	return false
+}
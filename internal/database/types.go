@@ -0,0 +1,97 @@
+package database
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SQLite type affinity of the declared column type
+// https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func (c Column) Affinity() string {
+	t := strings.ToUpper(c.Type)
+	switch {
+	case strings.Contains(t, "INT"):
+		return "INTEGER"
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return "TEXT"
+	case strings.Contains(t, "BLOB"), t == "":
+		return "BLOB"
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return "REAL"
+	default:
+		return "NUMERIC"
+	}
+}
+
+// declared as BOOL/BOOLEAN, stored as 0/1
+func (c Column) IsBool() bool {
+	return strings.Contains(strings.ToUpper(c.Type), "BOOL")
+}
+
+// declared as BLOB, untyped columns are left to the value
+func (c Column) IsBlob() bool {
+	return strings.Contains(strings.ToUpper(c.Type), "BLOB")
+}
+
+// declared as DATE, TIME, DATETIME or TIMESTAMP
+func (c Column) IsTime() bool {
+	t := strings.ToUpper(c.Type)
+	return strings.Contains(t, "DATE") || strings.Contains(t, "TIME")
+}
+
+// false for generated columns and hidden columns, SQLite computes those
+func (c Column) Writable() bool {
+	return c.Generated == "" && !c.Hidden
+}
+
+var checkInPattern = regexp.MustCompile(
+	"(?is)CHECK\\s*\\(\\s*[\"`\\[]?(\\w+)[\"`\\]]?\\s+IN\\s*\\(([^)]*)\\)\\s*\\)",
+)
+
+// finds CHECK(col IN (...)) constraints in a CREATE TABLE statement
+func parseCheckEnums(createSQL string) map[string][]string {
+	enums := make(map[string][]string)
+
+	for _, match := range checkInPattern.FindAllStringSubmatch(createSQL, -1) {
+		var values []string
+		for _, v := range splitValues(match[2]) {
+			values = append(values, unquoteLiteral(v))
+		}
+		enums[strings.ToLower(match[1])] = values
+	}
+
+	return enums
+}
+
+// splits a comma separated list of literals, ignoring commas inside quotes
+func splitValues(list string) []string {
+	var values []string
+	var cur strings.Builder
+	inQuote := false
+
+	for _, r := range list {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case r == ',' && !inQuote:
+			values = append(values, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+
+	if v := strings.TrimSpace(cur.String()); v != "" {
+		values = append(values, v)
+	}
+	return values
+}
+
+// strips the quotes from a SQL string literal and unescapes doubled quotes
+func unquoteLiteral(v string) string {
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	}
+	return v
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrConflict = errors.New("Row changed since the edit")
+
+// reverts the most recent change made through the UI. a change that fails,
+// ex. with ErrConflict, stays on the stack
+func (m *Manager) Undo() (*Change, error) {
+	c, ok := m.top(&m.undo)
+	if !ok {
+		return nil, fmt.Errorf("Nothing to undo")
+	}
+
+	if err := m.applyChange(c, c.After, c.Before); err != nil {
+		return nil, err
+	}
+
+	m.move(&m.undo, &m.redo)
+	return &c, nil
+}
+
+// reapplies the most recently undone change
+func (m *Manager) Redo() (*Change, error) {
+	c, ok := m.top(&m.redo)
+	if !ok {
+		return nil, fmt.Errorf("Nothing to redo")
+	}
+
+	if err := m.applyChange(c, c.Before, c.After); err != nil {
+		return nil, err
+	}
+
+	m.move(&m.redo, &m.undo)
+	return &c, nil
+}
+
+// the last change of the stack, left on it
+func (m *Manager) top(stack *[]Change) (Change, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := *stack
+	if len(s) == 0 {
+		return Change{}, false
+	}
+	return s[len(s)-1], true
+}
+
+// moves the last change of one stack onto the other once it was applied
+func (m *Manager) move(from, to *[]Change) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := *from
+	if len(s) == 0 {
+		return
+	}
+	*from = s[:len(s)-1]
+	*to = append(*to, s[len(s)-1])
+}
+
+// moves a row from one image to another, failing if the row no longer matches from.
+// a nil from inserts the row, a nil to deletes it
+func (m *Manager) applyChange(c Change, from, to []any) error {
+	q, err := m.writer()
+	if err != nil {
+		return err
+	}
+
+	keys := keyIndexes(c.Columns)
+	table := m.resolve(c.Table).quoted()
+
+	// the row as it is now
+	image := from
+	if image == nil {
+		image = to
+	}
+	where, whereArgs, err := rowWhere(q, table, c.Columns, keys, image)
+	if err != nil {
+		return err
+	}
+	current, err := fetchRow(q, table, c.Columns, where, whereArgs)
+	if err != nil {
+		return err
+	}
+
+	if from == nil {
+		// without a primary key an equal row is a duplicate, not a conflict
+		if current != nil && len(keys) > 0 {
+			return fmt.Errorf("%w: %s %s already exists", ErrConflict, c.Table, c.Key)
+		}
+	} else if current == nil || !sameRow(current, from) {
+		return fmt.Errorf("%w: %s %s was modified or removed", ErrConflict, c.Table, c.Key)
+	}
+
+	// generated columns follow from the others, an update writes only the
+	// columns that differ
+	var names []string
+	var values []any
+	for i, col := range c.Columns {
+		if !col.Writable() || from != nil && to != nil && sameStored(from[i], to[i]) {
+			continue
+		}
+		names = append(names, quoteIdentifier(col.Name))
+		if to != nil {
+			values = append(values, to[i])
+		}
+	}
+
+	var query string
+	var args []any
+	switch {
+	case from == nil:
+		marks := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), marks)
+		args = values
+	case to == nil:
+		query = fmt.Sprintf("DELETE FROM %s WHERE %s", table, where)
+		args = whereArgs
+	case len(names) == 0:
+		// the row images only differ in generated columns
+	default:
+		for i := range names {
+			names[i] += " = ?"
+		}
+		query = fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(names, ", "), where)
+		args = append(values, whereArgs...)
+	}
+
+	for i := range args {
+		args[i] = dbValue(args[i])
+	}
+
+	if query != "" {
+		if _, err := q.Exec(query, args...); err != nil {
+			return fmt.Errorf("Failed to apply change: %w", err)
+		}
+	}
+
+	// the reverted change still shows up as pending in transaction mode
+	kind := ChangeUpdate
+	if from == nil {
+		kind = ChangeInsert
+	} else if to == nil {
+		kind = ChangeDelete
+	}
+	m.addPending(Change{
+		Kind:    kind,
+		Table:   c.Table,
+		Columns: c.Columns,
+		Key:     c.Key,
+		Before:  from,
+		After:   to,
+	})
+
+	return nil
+}
+
+// compares two row images value by value
+func sameRow(a, b []any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !sameValue(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// the same value stored with the same type
+func sameStored(a, b any) bool {
+	return fmt.Sprintf("%T", a) == fmt.Sprintf("%T", b) && sameValue(a, b)
+}
+
+// compares blobs byte by byte and other values by their text
+func sameValue(a, b any) bool {
+	ab, aok := a.([]byte)
+	bb, bok := b.([]byte)
+	if aok || bok {
+		return aok && bok && bytes.Equal(ab, bb)
+	}
+
+	return (a == nil) == (b == nil) && valToString(a) == valToString(b)
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+
 	"dbtui/internal/database"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -20,8 +23,20 @@ type App struct {
 	focus          int // focused
 	help           help.Model
 	err            error
+	status         string // info message shown until the next key press
 	tableListModel tableList
 	tableModel     model
+	pending        pendingPanel
+	showPending    bool // pending changes panel replaces the table view
+	migrations     migrationsPanel
+	showMigrations bool // migrations panel replaces the table view
+	er             erPanel
+	dashboard      dashboard
+	showOverview   bool            // database overview replaces the table view
+	attach         attachPanel     // attach form, replaces the table view while open
+	showER         bool            // schema diagram replaces the list and table views
+	schemaDiff     schemaDiffPanel // schema comparison replaces the list and table views
+	confirmQuit    bool            // quitting with uncommitted changes
 	width          int
 	height         int
 	ready          bool
@@ -31,12 +46,25 @@ func NewApp(m *database.Manager) App {
 	help := help.New()
 	help.ShowAll = true
 
+	if m.ReadOnly() {
+		keys.setReadOnly()
+	}
+	if m.TxMode() {
+		keys.setTxMode()
+	}
+
 	return App{
 		store:          m,
 		focus:          listView,
 		help:           help,
 		tableListModel: newTableList(),
 		tableModel:     newModel(m),
+		pending:        newPendingPanel(),
+		er:             newERPanel(),
+		dashboard:      newDashboard(),
+		attach:         newAttachPanel(),
+		schemaDiff:     newSchemaDiffPanel(),
+		migrations:     newMigrationsPanel(),
 		ready:          false,
 	}
 }
@@ -44,6 +72,7 @@ func NewApp(m *database.Manager) App {
 func (a App) Init() tea.Cmd {
 	return tea.Batch(
 		loadTablesCmd(a.store),
+		loadDashboardCmd(a.store),
 		textinput.Blink,
 	)
 }
@@ -58,6 +87,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.width = msg.Width
 		a.height = msg.Height
 		a.ready = true
+		a.help.Width = msg.Width
 
 		helpHeight := lipgloss.Height(a.help.View(keys))
 		contentHeight := msg.Height - helpHeight - 2
@@ -68,14 +98,143 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// update all sub models with new dimensions
 		a.tableListModel.setSize(listWidth, contentHeight)
 		a.tableModel.setSize(contentWidth, contentHeight)
+		a.pending.setSize(contentWidth, contentHeight)
+		a.er.setSize(msg.Width, contentHeight)
+		a.dashboard.setSize(contentWidth, contentHeight)
+		a.attach.setSize(contentWidth, contentHeight)
+		a.schemaDiff.setSize(msg.Width, contentHeight)
+		a.migrations.setSize(contentWidth, contentHeight)
 
 	case tea.KeyMsg:
+		a.err = nil
+		a.status = ""
+
+		if a.confirmQuit {
+			a.confirmQuit = false
+			switch msg.String() {
+			case "y":
+				return a, commitCmd(a.store, true)
+			case "n":
+				return a, rollbackCmd(a.store, true)
+			}
+			return a, nil
+		}
+
+		// letters typed into an input or form are not shortcuts
+		typing := a.focus == tableView && a.tableModel.capturing() ||
+			a.focus == listView && a.tableListModel.list.SettingFilter() ||
+			a.attach.active() ||
+			a.schemaDiff.form != nil ||
+			a.showMigrations && a.migrations.form != nil
+
 		switch {
+		case typing && msg.Type == tea.KeyRunes:
+
 		case key.Matches(msg, keys.Quit):
+			if a.store.HasPending() {
+				a.confirmQuit = true
+				return a, nil
+			}
 			a.store.Close()
 			return a, tea.Quit
 
-		case key.Matches(msg, keys.Back):
+		case key.Matches(msg, keys.Pending):
+			a.showPending = !a.showPending
+			a.showMigrations = false
+			a.pending.setChanges(a.store.Pending())
+			return a, nil
+
+		case key.Matches(msg, keys.Commit):
+			return a, commitCmd(a.store, false)
+
+		case key.Matches(msg, keys.Rollback):
+			return a, rollbackCmd(a.store, false)
+
+		case key.Matches(msg, keys.Undo):
+			if a.store.ReadOnly() {
+				return a, errCmd(database.ErrReadOnly)
+			}
+			return a, undoCmd(a.store)
+
+		case key.Matches(msg, keys.Redo):
+			if a.store.ReadOnly() {
+				return a, errCmd(database.ErrReadOnly)
+			}
+			return a, redoCmd(a.store)
+
+		case key.Matches(msg, keys.Diagram):
+			a.showER = !a.showER
+			if a.showER {
+				return a, loadSchemaCmd(a.store)
+			}
+			return a, nil
+
+		case key.Matches(msg, keys.SchemaDiff) && !a.schemaDiff.open:
+			return a, a.schemaDiff.start(a.tableListModel.schemas)
+
+		// M copies Mermaid in the schema diagram
+		case key.Matches(msg, keys.Migrations) && !a.showER:
+			a.showMigrations = !a.showMigrations
+			a.migrations.closeForm()
+			if a.showMigrations {
+				a.showPending = false
+				return a, loadMigrationsCmd(a.store)
+			}
+			return a, nil
+
+		case key.Matches(msg, keys.Overview):
+			a.showOverview = !a.showOverview
+			a.showPending = false
+			a.focus = listView
+			a.tableListModel.setFocus(true)
+			if a.showOverview {
+				return a, loadDashboardCmd(a.store)
+			}
+			return a, nil
+
+		case key.Matches(msg, keys.Refresh):
+			return a, loadDashboardCmd(a.store)
+
+		case key.Matches(msg, keys.Back) && a.schemaDiff.open:
+			a.schemaDiff.close()
+			return a, nil
+
+		case key.Matches(msg, keys.Back) && a.showMigrations:
+			if a.migrations.form != nil {
+				a.migrations.closeForm()
+			} else {
+				a.showMigrations = false
+			}
+			return a, nil
+
+		case key.Matches(msg, keys.Back) && a.attach.active():
+			a.attach.close()
+			return a, nil
+
+		case key.Matches(msg, keys.Attach) && a.focus == listView:
+			a.showPending = false
+			return a, a.attach.open(a.store.ReadOnly())
+
+		case key.Matches(msg, keys.Detach) && a.focus == listView:
+			s, ok := a.tableListModel.selectedSchema()
+			if !ok || !s.attached() {
+				return a, statusCmd("Select an attached database in the list to detach it")
+			}
+			return a, detachCmd(a.store, s.name)
+
+		case key.Matches(msg, keys.Back) && (a.showER || !(a.focus == tableView && a.tableModel.handlesBack())):
+			if a.showER {
+				a.showER = false
+				return a, nil
+			}
+			if a.showPending {
+				a.showPending = false
+				return a, nil
+			}
+			if a.showOverview {
+				a.showOverview = false
+				return a, nil
+			}
 			if a.focus != listView {
 				a.focus = listView
 				a.tableListModel.setFocus(true)
@@ -86,20 +245,149 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.help.ShowAll = !a.help.ShowAll
 			return a, nil
 		}
+
+		if a.schemaDiff.open {
+			return a, a.schemaDiff.update(msg, a.store)
+		}
+
+		if a.showER {
+			if key.Matches(msg, keys.Enter) {
+				a.showER = false
+			}
+			return a, a.er.update(msg)
+		}
+
+		if a.attach.active() {
+			return a, a.attach.update(msg, a.store)
+		}
+
+		if a.showMigrations {
+			return a, a.migrations.update(msg, a.store)
+		}
+
+		if a.showPending {
+			a.pending.table, cmd = a.pending.table.Update(msg)
+			return a, cmd
+		}
+	case dashboardLoadedMsg:
+		a.dashboard.setInfo(msg)
+		return a, nil
+
+	case migrationsLoadedMsg:
+		a.migrations.setMigrations(msg)
+		return a, nil
+
+	case migratedMsg:
+		a.status = "Applied " + msg.migration.String()
+		if !msg.up {
+			a.status = "Rolled back " + msg.migration.String()
+		}
+		cmds = append(cmds, loadMigrationsCmd(a.store), loadTablesCmd(a.store), loadDashboardCmd(a.store))
+
+	case rowsGeneratedMsg:
+		var counts []string
+		for _, g := range msg.generated {
+			counts = append(counts, fmt.Sprintf("%d in %s", g.Inserted, g.Table))
+		}
+		a.status = fmt.Sprintf("Generated rows: %s (seed %d)", strings.Join(counts, ", "), msg.seed)
+		a.pending.setChanges(a.store.Pending())
+		cmds = append(cmds, loadTablesCmd(a.store), loadDashboardCmd(a.store))
+		if a.tableModel.name == msg.tableName {
+			cmds = append(cmds, loadTableDataCmd(a.store, a.tableModel.name, a.tableModel.filter, 0))
+		}
+
+	case schemaDiffLoadedMsg:
+		a.schemaDiff.setDiff(msg.diff)
+		return a, nil
+
+	case schemaLoadedMsg:
+		a.er.setTables(msg.tables)
+		return a, nil
+
 	case tablesLoadedMsg:
-		a.tableListModel.setTables(msg.tables)
+		a.tableListModel.setSchemas(msg.schemas)
+
+	case databasesChangedMsg:
+		if msg.detached {
+			a.status = "Detached " + msg.schema
+			// the open table may have been in it
+			if strings.HasPrefix(a.tableModel.name, msg.schema+".") {
+				a.showOverview = true
+				a.focus = listView
+			}
+		} else {
+			a.status = fmt.Sprintf("Attached %s, query its tables as %s.<table>", msg.schema, msg.schema)
+		}
+		cmds = append(cmds, loadTablesCmd(a.store), loadDashboardCmd(a.store))
 
 	case tableSelectedMsg:
 		a.focus = tableView
-		cmds = append(cmds, loadTableDataCmd(a.store, msg.tableName, 0))
+		a.showOverview = false
+		a.showMigrations = false
+		a.pending.setChanges(a.store.Pending())
+		// reloading the same table keeps its filter
+		filter := ""
+		if msg.tableName == a.tableModel.name {
+			filter = a.tableModel.filter
+		}
+		cmds = append(cmds, loadTableDataCmd(a.store, msg.tableName, filter, 0))
 
 	case editSubmitMsg:
 		cmds = append(cmds, execEditCmd(a.store, msg))
 
+	case insertSubmitMsg:
+		cmds = append(cmds, execInsertCmd(a.store, msg))
+
+	case deleteSubmitMsg:
+		cmds = append(cmds, execDeleteCmd(a.store, msg))
+
+	case queryResultMsg:
+		a.pending.setChanges(a.store.Pending())
+		// the query may have created, dropped or attached tables
+		cmds = append(cmds, loadTablesCmd(a.store))
+
+	case changeAppliedMsg:
+		action := "Redid"
+		if msg.undo {
+			action = "Undid"
+		}
+		a.status = fmt.Sprintf("%s %s on %s (%s)", action, msg.change.Kind, msg.change.Table, msg.change.Key)
+		a.pending.setChanges(a.store.Pending())
+		if a.tableModel.name == msg.change.Table {
+			cmds = append(cmds, loadTableDataCmd(a.store, a.tableModel.name, a.tableModel.filter, 0))
+		}
+
+	case txDoneMsg:
+		if msg.quit {
+			a.store.Close()
+			return a, tea.Quit
+		}
+		a.pending.setChanges(a.store.Pending())
+		cmds = append(cmds, loadTablesCmd(a.store))
+		// a rollback may undo schema changes too
+		if a.tableModel.name != "" {
+			cmds = append(cmds, loadTableDataCmd(a.store, a.tableModel.name, a.tableModel.filter, 0),
+				loadTableMetaCmd(a.store, a.tableModel.name))
+		}
+
+	case statusMsg:
+		a.status = msg.text
+
 	case errMsg:
 		a.err = msg.err
 	}
 
+	// the form also gets cursor blinks and other non-key messages
+	if _, ok := msg.(tea.KeyMsg); !ok && a.attach.active() {
+		cmds = append(cmds, a.attach.update(msg, a.store))
+	}
+	if _, ok := msg.(tea.KeyMsg); !ok && a.showMigrations && a.migrations.form != nil {
+		cmds = append(cmds, a.migrations.update(msg, a.store))
+	}
+	if _, ok := msg.(tea.KeyMsg); !ok && a.schemaDiff.form != nil {
+		cmds = append(cmds, a.schemaDiff.update(msg, a.store))
+	}
+
 	switch a.focus {
 	case listView:
 		mod, cmd = a.tableListModel.Update(msg)
@@ -122,15 +410,57 @@ func (a App) View() string {
 		return "Loading..."
 	}
 
+	main := a.tableModel.View()
+	if a.attach.active() {
+		main = a.attach.View()
+	} else if a.showMigrations {
+		main = a.migrations.View()
+	} else if a.showPending {
+		main = a.pending.View()
+	} else if a.showOverview || a.tableModel.name == "" {
+		main = a.dashboard.View()
+	}
+
 	content := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		a.tableListModel.View(),
-		a.tableModel.View(),
+		main,
 	)
+	if a.showER {
+		content = a.er.View()
+	}
+	if a.schemaDiff.open {
+		content = a.schemaDiff.View()
+	}
 
-	return lipgloss.JoinVertical(
-		lipgloss.Center,
-		content,
-		a.help.View(keys),
-	)
+	views := []string{content}
+	if status := a.statusView(); status != "" {
+		views = append(views, status)
+	}
+	views = append(views, a.help.View(keys))
+
+	return lipgloss.JoinVertical(lipgloss.Center, views...)
+}
+
+// quit prompt, errors and the transaction indicator
+func (a App) statusView() string {
+	if a.confirmQuit {
+		n := len(a.store.Pending())
+		return errorStyle.Render(fmt.Sprintf(
+			"%d uncommitted change(s): commit and quit (y) / discard and quit (n) / cancel (any key)", n))
+	}
+
+	if a.err != nil {
+		return errorStyle.Render("Error: " + a.err.Error())
+	}
+
+	if a.status != "" {
+		return statusStyle.Render(a.status)
+	}
+
+	if a.store.TxMode() {
+		return statusStyle.Render(fmt.Sprintf("TRANSACTION • %d pending change(s)", len(a.store.Pending())))
+	}
+
+	return ""
 }
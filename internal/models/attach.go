@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// values bound to the attach form
+type attachDraft struct {
+	path   string
+	schema string
+}
+
+// form attaching another database file, shown in place of the table view
+type attachPanel struct {
+	form   *huh.Form
+	draft  *attachDraft // bound to form
+	width  int
+	height int
+}
+
+func newAttachPanel() attachPanel {
+	return attachPanel{}
+}
+
+func (p *attachPanel) setSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+func (p attachPanel) active() bool {
+	return p.form != nil
+}
+
+func (p *attachPanel) open(readOnly bool) tea.Cmd {
+	description := "the file is opened like the main database"
+	if readOnly {
+		description = "the file is opened read-only"
+	}
+
+	draft := &attachDraft{}
+	p.draft = draft
+	p.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Database file").
+				Description(description).
+				Placeholder("./backup.db").
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("A path is required")
+					}
+					return nil
+				}).
+				Value(&draft.path),
+			huh.NewInput().
+				Title("Schema name").
+				Description("prefix of its tables in queries, ex. backup.users").
+				Placeholder("named after the file").
+				Validate(func(s string) error {
+					if strings.Contains(s, ".") {
+						return fmt.Errorf("The name cannot contain a dot")
+					}
+					return nil
+				}).
+				Value(&draft.schema),
+		),
+	).WithWidth(max(p.width-6, 30)).WithShowHelp(false)
+	return p.form.Init()
+}
+
+func (p *attachPanel) close() {
+	p.form = nil
+	p.draft = nil
+}
+
+// passes msg to the form, attaching the file once it is submitted
+func (p *attachPanel) update(msg tea.Msg, store *database.Manager) tea.Cmd {
+	form, cmd := p.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		p.form = f
+	}
+
+	switch p.form.State {
+	case huh.StateCompleted:
+		d := p.draft
+		p.close()
+		return attachCmd(store, strings.TrimSpace(d.path), strings.TrimSpace(d.schema))
+	case huh.StateAborted:
+		p.close()
+		return nil
+	}
+	return cmd
+}
+
+func (p attachPanel) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170")).
+		Render("Attach Database")
+
+	hint := dashboardDimStyle.Render("enter next • esc cancel")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlightColor).
+		Width(p.width - 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, "", p.form.View(), hint))
+}
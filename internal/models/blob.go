@@ -0,0 +1,346 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dumps larger than this are cut off, the full blob can still be saved
+const maxDumpBytes = 64 * 1024
+
+type blobPrompt int
+
+const (
+	noPrompt blobPrompt = iota
+	savePrompt
+	loadPrompt
+)
+
+// inspector for a single blob cell of the selected row
+type blobView struct {
+	col      int   // column being inspected
+	cols     []int // columns that can hold a blob
+	row      []any
+	data     []byte
+	isText   bool // value is stored as TEXT, not BLOB
+	showText bool // text content shown as text instead of hex
+	viewport viewport.Model
+	input    textinput.Model
+	prompt   blobPrompt
+}
+
+// sent to show an info message in the status line
+type statusMsg struct {
+	text string
+}
+
+func statusCmd(format string, a ...any) tea.Cmd {
+	return func() tea.Msg {
+		return statusMsg{fmt.Sprintf(format, a...)}
+	}
+}
+
+func (m *model) openBlob(row []any) error {
+	var cols []int
+	start := -1
+	for i, col := range m.columns {
+		_, isBlob := row[i].([]byte)
+		if isBlob || col.IsBlob() {
+			cols = append(cols, i)
+			if isBlob && start < 0 {
+				start = i
+			}
+		}
+	}
+	if len(cols) == 0 {
+		return errors.New("No BLOB columns in this table")
+	}
+	if start < 0 {
+		start = cols[0]
+	}
+
+	input := textinput.New()
+	input.Width = 40
+
+	m.openTab(blobTab, "Blob")
+	m.blob = blobView{
+		cols:     cols,
+		row:      row,
+		viewport: viewport.New(m.width-4, max(m.dataTable.Height()-2, 3)),
+		input:    input,
+	}
+	m.setBlobColumn(start)
+	return nil
+}
+
+func (m *model) setBlobColumn(i int) {
+	b := &m.blob
+	b.col = i
+	b.isText = false
+	switch v := b.row[i].(type) {
+	case []byte:
+		b.data = v
+	case string:
+		b.data = []byte(v)
+		b.isText = true
+	case nil:
+		b.data = nil
+	default:
+		b.data = []byte(database.EditValue(v))
+		b.isText = true
+	}
+
+	kind := detectContent(b.data)
+	b.showText = kind.text
+	b.viewport.SetContent(blobContent(b.data, kind, b.showText))
+	b.viewport.GotoTop()
+}
+
+func (m *model) updateBlob(msg tea.KeyMsg) tea.Cmd {
+	b := &m.blob
+
+	if b.prompt != noPrompt {
+		switch {
+		case key.Matches(msg, keys.Back):
+			b.prompt = noPrompt
+			b.input.Blur()
+			return nil
+		case key.Matches(msg, keys.Enter):
+			path := strings.TrimSpace(b.input.Value())
+			prompt := b.prompt
+			b.prompt = noPrompt
+			b.input.Blur()
+			if path == "" {
+				return nil
+			}
+			if prompt == savePrompt {
+				return m.saveBlob(path)
+			}
+			return m.loadBlob(path)
+		}
+
+		var cmd tea.Cmd
+		b.input, cmd = b.input.Update(msg)
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.closeTab(dataTab)
+	case key.Matches(msg, keys.Left):
+		m.moveTab(-1)
+	case key.Matches(msg, keys.Right):
+		m.moveTab(1)
+	case key.Matches(msg, keys.Tab):
+		m.activeTab = m.nextTab()
+	case key.Matches(msg, keys.Column):
+		for n, i := range b.cols {
+			if i == b.col {
+				m.setBlobColumn(b.cols[(n+1)%len(b.cols)])
+				break
+			}
+		}
+	case key.Matches(msg, keys.BlobText):
+		kind := detectContent(b.data)
+		if kind.text {
+			b.showText = !b.showText
+			b.viewport.SetContent(blobContent(b.data, kind, b.showText))
+		}
+	case key.Matches(msg, keys.BlobSave):
+		if b.data == nil {
+			return errCmd(errors.New("Value is NULL, nothing to save"))
+		}
+		b.prompt = savePrompt
+		b.input.Prompt = "Save to: "
+		b.input.SetValue(fmt.Sprintf("%s_%s%s", m.name, m.columns[b.col].Name, detectContent(b.data).ext))
+		b.input.CursorEnd()
+		return b.input.Focus()
+	case key.Matches(msg, keys.BlobLoad):
+		if m.store.ReadOnly() {
+			return errCmd(database.ErrReadOnly)
+		}
+		b.prompt = loadPrompt
+		b.input.Prompt = "Load from: "
+		b.input.SetValue("")
+		return b.input.Focus()
+	default:
+		var cmd tea.Cmd
+		b.viewport, cmd = b.viewport.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+func (m *model) saveBlob(path string) tea.Cmd {
+	if err := os.WriteFile(path, m.blob.data, 0o644); err != nil {
+		return errCmd(fmt.Errorf("Failed to save blob: %w", err))
+	}
+	return statusCmd("Saved %d bytes to %s", len(m.blob.data), path)
+}
+
+// replaces the inspected cell with the file contents
+func (m *model) loadBlob(path string) tea.Cmd {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errCmd(fmt.Errorf("Failed to load file: %w", err))
+	}
+
+	row := append([]any(nil), m.blob.row...)
+	row[m.blob.col] = data
+	return editSubmitCmd(m.name, m.columns, m.blob.row, row)
+}
+
+func (m model) blobView() string {
+	b := m.blob
+	col := m.columns[b.col]
+	kind := detectContent(b.data)
+
+	info := fmt.Sprintf("%s (%s) • %s • %s", col.Name, col.Type, database.FormatBytes(len(b.data)), kind.name)
+	if b.data == nil {
+		info = fmt.Sprintf("%s (%s) • NULL", col.Name, col.Type)
+	} else if b.isText {
+		info += " • stored as TEXT"
+	}
+
+	hint := fmt.Sprintf("%s %s • %s %s • %s %s",
+		keys.BlobSave.Help().Key, keys.BlobSave.Help().Desc,
+		keys.BlobLoad.Help().Key, keys.BlobLoad.Help().Desc,
+		keys.Column.Help().Key, keys.Column.Help().Desc)
+	if kind.text {
+		hint += fmt.Sprintf(" • %s %s", keys.BlobText.Help().Key, keys.BlobText.Help().Desc)
+	}
+
+	footer := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(hint)
+	if b.prompt != noPrompt {
+		footer = b.input.View()
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.appBoundaryView(info),
+		b.viewport.View(),
+		footer,
+	)
+}
+
+// what a blob looks like it contains
+type blobKind struct {
+	name string
+	ext  string // file extension used when saving
+	text bool   // can be shown as text
+}
+
+// guesses the content from magic numbers, falls back to text or binary
+func detectContent(data []byte) blobKind {
+	switch {
+	case len(data) == 0:
+		return blobKind{"empty", ".bin", false}
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		name := "PNG image"
+		if len(data) >= 24 {
+			w, h := binary.BigEndian.Uint32(data[16:20]), binary.BigEndian.Uint32(data[20:24])
+			name = fmt.Sprintf("PNG image %dx%d", w, h)
+		}
+		return blobKind{name, ".png", false}
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return blobKind{"JPEG image", ".jpg", false}
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return blobKind{"GIF image", ".gif", false}
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return blobKind{"PDF document", ".pdf", false}
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return blobKind{"gzip data", ".gz", false}
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return blobKind{"zip archive", ".zip", false}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return blobKind{"JSON", ".json", true}
+	}
+	if isText(data) {
+		return blobKind{"UTF-8 text", ".txt", true}
+	}
+	return blobKind{"binary", ".bin", false}
+}
+
+// valid UTF-8 without control characters other than whitespace
+func isText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func blobContent(data []byte, kind blobKind, asText bool) string {
+	if data == nil {
+		return database.NullDisplay + " NULL"
+	}
+	if !asText {
+		return hexDump(data)
+	}
+
+	if kind.name == "JSON" {
+		var out bytes.Buffer
+		if err := json.Indent(&out, bytes.TrimSpace(data), "", "  "); err == nil {
+			return out.String()
+		}
+	}
+	return string(data)
+}
+
+// offset, 16 bytes of hex and their printable ASCII per line
+func hexDump(data []byte) string {
+	var b strings.Builder
+	n := min(len(data), maxDumpBytes)
+
+	for off := 0; off < n; off += 16 {
+		line := data[off:min(off+16, n)]
+
+		fmt.Fprintf(&b, "%08x  ", off)
+		for i := range 16 {
+			if i < len(line) {
+				fmt.Fprintf(&b, "%02x ", line[i])
+			} else {
+				b.WriteString("   ")
+			}
+			if i == 7 {
+				b.WriteString(" ")
+			}
+		}
+
+		b.WriteString(" |")
+		for _, c := range line {
+			if c >= 0x20 && c < 0x7f {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteString("|\n")
+	}
+
+	if len(data) > n {
+		fmt.Fprintf(&b, "... %d more bytes, save the blob to see all of it\n", len(data)-n)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
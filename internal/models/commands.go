@@ -1,13 +1,16 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+
 	"dbtui/internal/database"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type tablesLoadedMsg struct {
-	tables []string
+	schemas []database.Schema
 }
 
 type tableSelectedMsg struct {
@@ -16,25 +19,132 @@ type tableSelectedMsg struct {
 
 type tableDataLoadedMsg struct {
 	columns   []database.Column
-	rows      [][]string
+	rows      [][]any
+	tableName string
+	filter    string
+}
+
+// indexes, triggers and definition of a table, they only change with the schema
+type tableMetaLoadedMsg struct {
 	tableName string
+	indexes   []database.Index
+	triggers  []database.Trigger
+	def       database.TableDefinition
 }
 
 type queryResultMsg struct {
 	columns []string
-	rows    [][]string
+	rows    [][]any
 	err     error
 }
 
 type rowSelectedMsg struct {
-	row []string
+	row []any
 }
 
 type editSubmitMsg struct {
 	tableName string
-	id        string
 	columns   []database.Column
-	row       []string
+	old       []any
+	row       []any
+}
+
+type insertSubmitMsg struct {
+	tableName string
+	columns   []database.Column
+	row       []any
+}
+
+type deleteSubmitMsg struct {
+	tableName string
+	columns   []database.Column
+	row       []any
+}
+
+// sent after a change was undone or redone
+type changeAppliedMsg struct {
+	change database.Change
+	undo   bool
+}
+
+// sent after a commit or rollback in transaction mode
+type txDoneMsg struct {
+	quit bool
+}
+
+// result of evaluating a path in the JSON tab
+type jsonExtractedMsg struct {
+	path  string
+	value any
+	err   error
+}
+
+// foreign keys referencing the selected row
+type referencesLoadedMsg struct {
+	refs []reference
+}
+
+type schemaLoadedMsg struct {
+	tables []database.TableSchema
+}
+
+type dashboardLoadedMsg struct {
+	info    map[string]string
+	options []string
+	tables  []database.TableInfo
+	sized   bool // dbstat was available
+}
+
+// sent after an index was created or dropped
+type indexesChangedMsg struct {
+	tableName string
+	indexes   []database.Index
+	statement string
+}
+
+// sent after a trigger was saved or dropped
+type triggersChangedMsg struct {
+	tableName string
+	triggers  []database.Trigger
+	status    string
+}
+
+// tables the open table can be compared with
+type diffTablesLoadedMsg struct {
+	tableName string
+	tables    []string
+}
+
+type diffLoadedMsg struct {
+	diff *database.TableDiff
+}
+
+// sent after a database was attached or detached
+type databasesChangedMsg struct {
+	schema   string
+	detached bool
+}
+
+type schemaDiffLoadedMsg struct {
+	diff *database.SchemaDiff
+}
+
+type migrationsLoadedMsg struct {
+	dir        string
+	migrations []database.Migration
+	err        error
+}
+
+type migratedMsg struct {
+	migration database.Migration
+	up        bool
+}
+
+// sent after generated rows were inserted, parents of the table come first
+type rowsGeneratedMsg struct {
+	tableName string
+	generated []database.Seeded
+	seed      int64
 }
 
 type errMsg struct {
@@ -42,12 +152,198 @@ type errMsg struct {
 }
 
 func loadTablesCmd(m *database.Manager) tea.Cmd {
+	return func() tea.Msg {
+		schemas, err := m.ListSchemas()
+		if err != nil {
+			return errMsg{err}
+		}
+		return tablesLoadedMsg{schemas}
+	}
+}
+
+func loadSchemaCmd(m *database.Manager) tea.Cmd {
+	return func() tea.Msg {
+		tables, err := m.GetSchema()
+		if err != nil {
+			return errMsg{err}
+		}
+		return schemaLoadedMsg{tables}
+	}
+}
+
+// gathers the database overview, row counts and table sizes
+func loadDashboardCmd(m *database.Manager) tea.Cmd {
+	return func() tea.Msg {
+		info, err := m.GetDBInfo()
+		if err != nil {
+			return errMsg{fmt.Errorf("Failed to get database info: %w", err)}
+		}
+
+		options, err := m.GetCompileOptions()
+		if err != nil {
+			return errMsg{err}
+		}
+
+		sizes, err := m.GetTableSizes()
+		if err != nil {
+			return errMsg{err}
+		}
+
+		names, err := m.ListTables()
+		if err != nil {
+			return errMsg{err}
+		}
+
+		tables := make([]database.TableInfo, len(names))
+		for i, name := range names {
+			t, err := m.GetTableInfo(name)
+			if err != nil {
+				return errMsg{err}
+			}
+			if size, ok := sizes[name]; ok {
+				t.Size = size
+			}
+			tables[i] = *t
+		}
+
+		return dashboardLoadedMsg{info, options, tables, sizes != nil}
+	}
+}
+
+// runs a CREATE INDEX or DROP INDEX statement and reloads the indexes of the table
+func execIndexCmd(m *database.Manager, tableName, statement string) tea.Cmd {
+	return func() tea.Msg {
+		if _, _, err := m.ExecuteQuery(statement); err != nil {
+			return errMsg{err}
+		}
+
+		indexes, err := m.GetIndexes(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+		return indexesChangedMsg{tableName, indexes, statement}
+	}
+}
+
+// creates or replaces a trigger, oldName is empty for a new one
+func saveTriggerCmd(m *database.Manager, tableName, oldName, statement string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.SaveTrigger(tableName, oldName, statement); err != nil {
+			return errMsg{err}
+		}
+
+		triggers, err := m.GetTriggers(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+		status := "Created trigger"
+		if oldName != "" {
+			status = "Replaced trigger " + oldName
+		}
+		return triggersChangedMsg{tableName, triggers, status}
+	}
+}
+
+func dropTriggerCmd(m *database.Manager, tableName, triggerName string) tea.Cmd {
+	return func() tea.Msg {
+		statement := m.DropTriggerSQL(tableName, triggerName)
+		if _, _, err := m.ExecuteQuery(statement); err != nil {
+			return errMsg{err}
+		}
+
+		triggers, err := m.GetTriggers(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+		return triggersChangedMsg{tableName, triggers, "Ran " + statement}
+	}
+}
+
+// attaches a database file, schema is the name given to it or empty to name it after the file
+func attachCmd(m *database.Manager, path, schema string) tea.Cmd {
+	return func() tea.Msg {
+		schema, err := m.Attach(path, schema)
+		if err != nil {
+			return errMsg{err}
+		}
+		return databasesChangedMsg{schema: schema}
+	}
+}
+
+func detachCmd(m *database.Manager, schema string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.Detach(schema); err != nil {
+			return errMsg{err}
+		}
+		return databasesChangedMsg{schema: schema, detached: true}
+	}
+}
+
+// reads the migration files and which of them are applied, a missing
+// directory is shown in the panel rather than as an error
+func loadMigrationsCmd(m *database.Manager) tea.Cmd {
+	return func() tea.Msg {
+		dir := m.MigrationsDir()
+		migrations, err := m.Migrations(dir)
+		return migrationsLoadedMsg{dir, migrations, err}
+	}
+}
+
+// applies the next migration or rolls back the last one
+func migrateCmd(m *database.Manager, dir string, up bool) tea.Cmd {
+	return func() tea.Msg {
+		run := m.MigrateUp
+		if !up {
+			run = m.MigrateDown
+		}
+		mig, err := run(dir)
+		if err != nil {
+			return errMsg{err}
+		}
+		return migratedMsg{mig, up}
+	}
+}
+
+// fills the table and its empty parents with generated rows
+func generateCmd(m *database.Manager, tableName string, opts database.GenerateOptions) tea.Cmd {
+	return func() tea.Msg {
+		generated, seed, err := m.GenerateRows([]string{tableName}, opts)
+		if err != nil {
+			return errMsg{err}
+		}
+		return rowsGeneratedMsg{tableName, generated, seed}
+	}
+}
+
+// compares the schemas of two databases on the connection
+func schemaDiffCmd(m *database.Manager, left, right string) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := m.DiffSchemas(left, right)
+		if err != nil {
+			return errMsg{err}
+		}
+		return schemaDiffLoadedMsg{diff}
+	}
+}
+
+func diffTablesCmd(m *database.Manager, tableName string) tea.Cmd {
 	return func() tea.Msg {
 		tables, err := m.ListTables()
 		if err != nil {
 			return errMsg{err}
 		}
-		return tablesLoadedMsg{tables}
+		return diffTablesLoadedMsg{tableName, tables}
+	}
+}
+
+// compares the rows of two tables
+func diffCmd(m *database.Manager, left, right string) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := m.DiffTables(left, right)
+		if err != nil {
+			return errMsg{err}
+		}
+		return diffLoadedMsg{diff}
 	}
 }
 
@@ -57,23 +353,88 @@ func selectTableCmd(name string) tea.Cmd {
 	}
 }
 
-func loadTableDataCmd(m *database.Manager, tableName string, offset int) tea.Cmd {
+// loads a page of the table, filter is a WHERE clause or empty
+func loadTableDataCmd(m *database.Manager, tableName, filter string, offset int) tea.Cmd {
 	return func() tea.Msg {
 		columns, err := m.GetTableSchema(tableName)
 		if err != nil {
 			return errMsg{err}
 		}
 
-		rows, err := m.GetTableData(tableName, 100, offset)
+		rows, err := m.FilterTable(tableName, filter, 100, offset)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		return tableDataLoadedMsg{columns, rows, tableName, filter}
+	}
+}
+
+// loads what the Info tab shows besides the columns, once per table and
+// again when the schema may have changed
+func loadTableMetaCmd(m *database.Manager, tableName string) tea.Cmd {
+	return func() tea.Msg {
+		indexes, err := m.GetIndexes(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		triggers, err := m.GetTriggers(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		def, err := m.GetTableDefinition(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		return tableMetaLoadedMsg{tableName, indexes, triggers, def}
+	}
+}
+
+// loads the parent row of the foreign key containing column
+func followRefCmd(m *database.Manager, tableName string, columns []database.Column, row []any, column string) tea.Cmd {
+	return func() tea.Msg {
+		fks, err := m.GetForeignKeys(tableName)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		for _, fk := range fks {
+			for _, name := range fk.Columns {
+				if strings.EqualFold(name, column) {
+					where := database.MatchWhere(fk.RefColumns, rowValues(columns, row, fk.Columns))
+					return loadTableDataCmd(m, fk.RefTable, where, 0)()
+				}
+			}
+		}
+		return errMsg{fmt.Errorf("No foreign key on %s", column)}
+	}
+}
+
+// finds the foreign keys referencing the table and counts the rows pointing at row
+func referencesCmd(m *database.Manager, tableName string, columns []database.Column, row []any) tea.Cmd {
+	return func() tea.Msg {
+		fks, err := m.GetReferencingKeys(tableName)
 		if err != nil {
 			return errMsg{err}
 		}
 
-		return tableDataLoadedMsg{columns, rows, tableName}
+		refs := make([]reference, len(fks))
+		for i, fk := range fks {
+			where := database.MatchWhere(fk.Columns, rowValues(columns, row, fk.RefColumns))
+			count, err := m.CountWhere(fk.Table, where)
+			if err != nil {
+				return errMsg{err}
+			}
+			refs[i] = reference{fk, where, count}
+		}
+		return referencesLoadedMsg{refs}
 	}
 }
 
-func selectRowCmd(row []string) tea.Cmd {
+func selectRowCmd(row []any) tea.Cmd {
 	return func() tea.Msg {
 		return rowSelectedMsg{
 			row: row,
@@ -81,20 +442,60 @@ func selectRowCmd(row []string) tea.Cmd {
 	}
 }
 
-func editSubmitCmd(tableName, id string, columns []database.Column, row []string) tea.Cmd {
+func editSubmitCmd(tableName string, columns []database.Column, old, row []any) tea.Cmd {
 	return func() tea.Msg {
 		return editSubmitMsg{
 			tableName: tableName,
-			id: id,
-			columns: columns,
-			row: row,
+			columns:   columns,
+			old:       old,
+			row:       row,
+		}
+	}
+}
+
+func insertSubmitCmd(tableName string, columns []database.Column, row []any) tea.Cmd {
+	return func() tea.Msg {
+		return insertSubmitMsg{
+			tableName: tableName,
+			columns:   columns,
+			row:       row,
+		}
+	}
+}
+
+func deleteSubmitCmd(tableName string, columns []database.Column, row []any) tea.Cmd {
+	return func() tea.Msg {
+		return deleteSubmitMsg{
+			tableName: tableName,
+			columns:   columns,
+			row:       row,
 		}
 	}
 }
 
 func execEditCmd(m *database.Manager, msg editSubmitMsg) tea.Cmd {
 	return func() tea.Msg {
-		err := m.EditRow(msg.tableName, msg.id, msg.columns, msg.row)
+		err := m.EditRow(msg.tableName, msg.columns, msg.old, msg.row)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return tableSelectedMsg{msg.tableName}
+	}
+}
+
+func execInsertCmd(m *database.Manager, msg insertSubmitMsg) tea.Cmd {
+	return func() tea.Msg {
+		err := m.InsertRow(msg.tableName, msg.columns, msg.row)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return tableSelectedMsg{msg.tableName}
+	}
+}
+
+func execDeleteCmd(m *database.Manager, msg deleteSubmitMsg) tea.Cmd {
+	return func() tea.Msg {
+		err := m.DeleteRow(msg.tableName, msg.columns, msg.row)
 		if err != nil {
 			return errMsg{err: err}
 		}
@@ -102,6 +503,30 @@ func execEditCmd(m *database.Manager, msg editSubmitMsg) tea.Cmd {
 	}
 }
 
+func commitCmd(m *database.Manager, quit bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.Commit(); err != nil {
+			return errMsg{err}
+		}
+		return txDoneMsg{quit}
+	}
+}
+
+func rollbackCmd(m *database.Manager, quit bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.Rollback(); err != nil {
+			return errMsg{err}
+		}
+		return txDoneMsg{quit}
+	}
+}
+
+func errCmd(err error) tea.Cmd {
+	return func() tea.Msg {
+		return errMsg{err}
+	}
+}
+
 func execQueryCmd(m *database.Manager, query string) tea.Cmd {
 	return func() tea.Msg {
 		columns, rows, err := m.ExecuteQuery(query)
@@ -109,3 +534,30 @@ func execQueryCmd(m *database.Manager, query string) tea.Cmd {
 		return queryResultMsg{columns, rows, err}
 	}
 }
+
+func jsonExtractCmd(m *database.Manager, doc, path string) tea.Cmd {
+	return func() tea.Msg {
+		val, err := m.JSONExtract(doc, path)
+		return jsonExtractedMsg{path, val, err}
+	}
+}
+
+func undoCmd(m *database.Manager) tea.Cmd {
+	return func() tea.Msg {
+		c, err := m.Undo()
+		if err != nil {
+			return errMsg{err}
+		}
+		return changeAppliedMsg{*c, true}
+	}
+}
+
+func redoCmd(m *database.Manager) tea.Cmd {
+	return func() tea.Msg {
+		c, err := m.Redo()
+		if err != nil {
+			return errMsg{err}
+		}
+		return changeAppliedMsg{*c, false}
+	}
+}
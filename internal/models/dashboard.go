@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// database overview shown while no table is selected
+type dashboard struct {
+	info    map[string]string
+	options []string // compile options
+	table   table.Model
+	loaded  bool
+	sized   bool // table sizes are known
+	width   int
+	height  int
+}
+
+// rows of the overview, keys are from Manager.GetDBInfo
+var dashboardFields = []struct{ key, title string }{
+	{"path", "Path"},
+	{"attached", "Attached"},
+	{"size", "Size"},
+	{"page_size", "Page size"},
+	{"page_count", "Pages"},
+	{"freelist_count", "Free pages"},
+	{"encoding", "Encoding"},
+	{"journal_mode", "Journal mode"},
+	{"user_version", "User version"},
+	{"foreign_keys", "Foreign keys"},
+	{"sqlite_version", "SQLite version"},
+	{"table_count", "Tables"},
+}
+
+var (
+	dashboardKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Width(16)
+	dashboardDimStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+func newDashboard() dashboard {
+	return dashboard{table: newTable()}
+}
+
+func (d *dashboard) setSize(width, height int) {
+	d.width = width
+	d.height = height
+	d.table.SetWidth(width - 4)
+	d.fitTable()
+}
+
+// shows every table when there is room, the fields and compile options take about 20 lines
+func (d *dashboard) fitTable() {
+	d.table.SetHeight(min(len(d.table.Rows())+2, max(d.height-20, 3)))
+}
+
+func (d *dashboard) setInfo(msg dashboardLoadedMsg) {
+	d.info = msg.info
+	d.options = msg.options
+	d.loaded = true
+	d.sized = msg.sized
+
+	d.table.SetRows(nil)
+	d.table.SetColumns([]table.Column{
+		{Title: "Table", Width: 20},
+		{Title: "Type", Width: 6},
+		{Title: "Rows", Width: 10},
+		{Title: "Columns", Width: 8},
+		{Title: "Size", Width: 10},
+	})
+
+	rows := make([]table.Row, len(msg.tables))
+	for i, t := range msg.tables {
+		size := "-"
+		if t.Size >= 0 {
+			size = database.FormatBytes(int(t.Size))
+		}
+		rows[i] = table.Row{t.Name, t.Type, fmt.Sprintf("%d", t.RowCount), fmt.Sprintf("%d", t.ColumnCount), size}
+	}
+	d.table.SetRows(rows)
+	d.fitTable()
+	d.table.GotoTop()
+	d.table.Blur()
+}
+
+func (d dashboard) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170")).
+		Render("Database Overview")
+
+	body := "Loading..."
+	if d.loaded {
+		var lines []string
+		for _, f := range dashboardFields {
+			lines = append(lines, dashboardKeyStyle.Render(f.title)+d.info[f.key])
+		}
+
+		options := dashboardDimStyle.Width(d.width - 4).Render(
+			"Compile options: " + strings.Join(d.options, " "))
+
+		hint := fmt.Sprintf("%s %s", keys.Refresh.Help().Key, keys.Refresh.Help().Desc)
+		if !d.sized {
+			hint += " • table sizes need SQLite built with dbstat"
+		}
+
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			strings.Join(lines, "\n"),
+			"",
+			baseStyle.Render(d.table.View()),
+			"",
+			options,
+			"",
+			dashboardDimStyle.Render(hint),
+		)
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlightColor).
+		Width(d.width - 2).
+		Height(d.height).
+		MaxHeight(d.height + 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body))
+}
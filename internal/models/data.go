@@ -2,10 +2,13 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"dbtui/internal/database"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -18,47 +21,98 @@ func (m *model) dataView() string {
 		Bold(true).
 		Foreground(lipgloss.Color("170")).
 		Render(fmt.Sprintf("Table: %s (Page %d)", m.name, m.currentPage+1))
+	title += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(m.columnsInfo())
+
+	filter := ""
+	if m.filtering {
+		filter = m.filterInput.View()
+	} else if m.filter != "" {
+		filter = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("WHERE " + m.filter)
+	}
 
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		title,
-		"",
-		baseStyle.Render(m.dataTable.View()),
+		filter,
+		m.gridView(m.dataTable, m.rows, m.visibleCols, m.layouts[m.name], m.dataTop),
 	)
 }
 
-func (m *model) setDataTable(tableName string, columns []database.Column, rows [][]string) {
+// table with the selected cell highlighted and the columns scrolled off each side
+func (m model) gridView(t table.Model, raw [][]any, visible []int, l *columnLayout, top int) string {
+	if l == nil {
+		return baseStyle.Render(t.View())
+	}
+
+	grid := baseStyle.Render(renderGrid(t, raw, visible, l.focused, top))
+	left, right := l.offscreen(visible)
+	if more := offscreenView(left, right, lipgloss.Width(grid)); more != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, grid, more)
+	}
+	return grid
+}
+
+// opens the filter input on the Data tab with text to edit
+func (m *model) editFilter(text string) tea.Cmd {
+	m.closeTab(dataTab)
+	m.filtering = true
+	m.filterInput.SetValue(text)
+	m.filterInput.CursorEnd()
+	return m.filterInput.Focus()
+}
+
+// enter applies the filter, an empty one shows all rows again
+func (m *model) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.filtering = false
+		m.filterInput.Blur()
+		return nil
+	case key.Matches(msg, keys.Enter):
+		m.filtering = false
+		m.filterInput.Blur()
+		return loadTableDataCmd(m.store, m.name, strings.TrimSpace(m.filterInput.Value()), 0)
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	return cmd
+}
+
+func (m *model) setDataTable(tableName string, columns []database.Column, rows [][]any) {
 	m.name = tableName
 	m.currentPage = 0
 	m.columns = columns
-	m.activeTab = dataTab
+	m.rows = rows
+	m.closeTab(dataTab)
 
 	m.dataTable = newTable()
+	m.dataTable.SetWidth(m.width - 2)
+	m.applyLayout()
+	m.dataTable.GotoTop()
+	m.dataTop = 0
+	m.dataTable.Focus()
+	m.setInfoTable()
+}
 
-	// convert cols to bubbles
-	tableCols := make([]table.Column, len(columns))
-	for i, col := range columns {
-		width := 10
-		if len(col.Name) > width {
-			width = len(col.Name) + 2
-		}
-
-		tableCols[i] = table.Column{
-			Title: col.Name,
-			Width: width,
-		}
+// raw row under the cursor, nil if the table is empty
+func (m *model) selectedData() []any {
+	i := m.dataTable.Cursor()
+	if i < 0 || i >= len(m.rows) {
+		return nil
 	}
+	return m.rows[i]
+}
 
-	m.dataTable.SetColumns(tableCols)
-
-	// convert rows to bubbles
+// formats values for display, NULL is shown as database.NullDisplay
+func toTableRows(rows [][]any) []table.Row {
 	tableRows := make([]table.Row, len(rows))
 	for i, row := range rows {
-		tableRows[i] = row
+		cells := make(table.Row, len(row))
+		for j, v := range row {
+			cells[j] = database.FormatValue(v)
+		}
+		tableRows[i] = cells
 	}
-
-	m.dataTable.SetRows(tableRows)
-	m.dataTable.GotoTop()
-	m.dataTable.Focus()
-	m.setInfoTable()
+	return tableRows
 }
@@ -0,0 +1,244 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// what the Diff tab shows
+const (
+	diffRows       = iota
+	diffSQL        // statements making the right table like the left
+	diffSQLReverse // statements making the left table like the right
+)
+
+// rows of the open table compared with another table
+type diffView struct {
+	form     *huh.Form // picks the table to compare with
+	other    *string   // bound to form
+	diff     *database.TableDiff
+	mode     int
+	viewport viewport.Model
+}
+
+var (
+	diffLeftStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	diffRightStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	diffLeftCellStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("231")).Background(lipgloss.Color("124"))
+	diffRightCellStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("231")).Background(lipgloss.Color("28"))
+	diffHeaderStyle    = lipgloss.NewStyle().Bold(true)
+)
+
+// opens a select of the other tables, the ones with the same name in another
+// database first
+func (m *model) openDiff(tables []string) tea.Cmd {
+	base := m.name[strings.LastIndex(m.name, ".")+1:]
+	var same, others []huh.Option[string]
+	for _, t := range tables {
+		switch {
+		case t == m.name:
+		case strings.EqualFold(t[strings.LastIndex(t, ".")+1:], base):
+			same = append(same, huh.NewOption(t, t))
+		default:
+			others = append(others, huh.NewOption(t, t))
+		}
+	}
+	if len(same)+len(others) == 0 {
+		return statusCmd("No other table to compare %s with", m.name)
+	}
+
+	other := new(string)
+	m.openTab(diffTab, "Diff")
+	m.diff = diffView{
+		other:    other,
+		viewport: viewport.New(m.width-4, m.diffHeight()),
+	}
+	m.diff.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Compare " + m.name + " with").
+				Description("rows are matched on the primary key of " + m.name).
+				Options(append(same, others...)...).
+				Value(other),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.diff.form.Init()
+}
+
+func (m *model) updateDiff(msg tea.KeyMsg) tea.Cmd {
+	d := &m.diff
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.closeTab(dataTab)
+	case key.Matches(msg, keys.Left):
+		m.moveTab(-1)
+	case key.Matches(msg, keys.Right):
+		m.moveTab(1)
+	case key.Matches(msg, keys.Tab):
+		m.activeTab = m.nextTab()
+	case d.diff == nil:
+	case key.Matches(msg, keys.DiffSQL):
+		d.mode = (d.mode + 1) % 3
+		d.viewport.GotoTop()
+		m.renderDiff()
+	case key.Matches(msg, keys.Copy):
+		if d.mode == diffRows {
+			return statusCmd("Press %s to show the SQL to copy", keys.DiffSQL.Help().Key)
+		}
+		return copyCmd("sync SQL", m.diffStatements())
+	default:
+		var cmd tea.Cmd
+		d.viewport, cmd = d.viewport.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+func (m *model) setDiff(diff *database.TableDiff) {
+	m.diff.diff = diff
+	m.diff.mode = diffRows
+	m.renderDiff()
+}
+
+// the sync statements of the SQL mode, one per line
+func (m *model) diffStatements() string {
+	stmts := m.diff.diff.SyncSQL(m.diff.mode == diffSQLReverse)
+	if len(stmts) == 0 {
+		return "-- the tables have the same rows"
+	}
+	return strings.Join(stmts, ";\n") + ";"
+}
+
+// fills the viewport with the differing rows or the sync SQL
+func (m *model) renderDiff() {
+	d := &m.diff
+	width := max(d.viewport.Width, 10)
+
+	if d.mode != diffRows {
+		lines := strings.Split(highlightSQL(m.diffStatements()), "\n")
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, width, "…")
+		}
+		d.viewport.SetContent(strings.Join(lines, "\n"))
+		return
+	}
+
+	diff := d.diff
+	if len(diff.Rows) == 0 {
+		d.viewport.SetContent("The tables have the same rows")
+		return
+	}
+
+	// columns sized to their values, up to a limit
+	widths := make([]int, len(diff.Columns))
+	for i, col := range diff.Columns {
+		widths[i] = lipgloss.Width(col.Name)
+	}
+	for _, r := range diff.Rows {
+		for _, row := range [][]any{r.Left, r.Right} {
+			for i := range row {
+				widths[i] = max(widths[i], lipgloss.Width(database.FormatValue(row[i])))
+			}
+		}
+	}
+	for i := range widths {
+		widths[i] = min(max(widths[i], minColWidth), 24)
+	}
+
+	line := func(marker string, row []any, changed []bool, style, cellStyle lipgloss.Style) string {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cell := fitCell(database.FormatValue(v), widths[i])
+			if changed != nil && changed[i] {
+				cells[i] = cellStyle.Render(cell)
+			} else if changed == nil {
+				cells[i] = style.Render(cell)
+			} else {
+				cells[i] = cell
+			}
+		}
+		return ansi.Truncate(style.Render(marker)+" "+strings.Join(cells, "  "), width, "…")
+	}
+
+	header := make([]string, len(diff.Columns))
+	for i, col := range diff.Columns {
+		header[i] = fitCell(col.Name, widths[i])
+	}
+	lines := []string{ansi.Truncate("  "+diffHeaderStyle.Render(strings.Join(header, "  ")), width, "…")}
+
+	for _, r := range diff.Rows {
+		switch r.Kind {
+		case database.DiffChanged:
+			lines = append(lines,
+				line("<", r.Left, r.Changed, diffLeftStyle, diffLeftCellStyle),
+				line(">", r.Right, r.Changed, diffRightStyle, diffRightCellStyle))
+		case database.DiffOnlyLeft:
+			lines = append(lines, line("<", r.Left, nil, diffLeftStyle, diffLeftCellStyle))
+		case database.DiffOnlyRight:
+			lines = append(lines, line(">", r.Right, nil, diffRightStyle, diffRightCellStyle))
+		}
+	}
+	d.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// rows of the tab left for the viewport
+func (m *model) diffHeight() int {
+	return max(m.height-14, 5)
+}
+
+func (m model) diffView() string {
+	d := m.diff
+	if d.form != nil {
+		return m.appBoundaryView("Diff "+m.name) + "\n" + d.form.View()
+	}
+	if d.diff == nil {
+		return m.appBoundaryView("Diff "+m.name) + "\n" + "Comparing..."
+	}
+
+	diff := d.diff
+	title := fmt.Sprintf("Diff of %s (<) and %s (>)", diff.Left, diff.Right)
+	switch d.mode {
+	case diffSQL:
+		title = fmt.Sprintf("SQL making %s like %s", diff.Right, diff.Left)
+	case diffSQLReverse:
+		title = fmt.Sprintf("SQL making %s like %s", diff.Left, diff.Right)
+	}
+
+	summary := fmt.Sprintf("%s • %s • %d changed • %d same",
+		diffLeftStyle.Render(fmt.Sprintf("%d only in %s", diff.Count(database.DiffOnlyLeft), diff.Left)),
+		diffRightStyle.Render(fmt.Sprintf("%d only in %s", diff.Count(database.DiffOnlyRight), diff.Right)),
+		diff.Count(database.DiffChanged), diff.Same)
+	var notes []string
+	if len(diff.LeftColumns) > 0 {
+		notes = append(notes, fmt.Sprintf("only in %s: %s", diff.Left, strings.Join(diff.LeftColumns, ", ")))
+	}
+	if len(diff.RightColumns) > 0 {
+		notes = append(notes, fmt.Sprintf("only in %s: %s", diff.Right, strings.Join(diff.RightColumns, ", ")))
+	}
+	if len(notes) > 0 {
+		summary += "\n" + recordTypeStyle.Render("not compared, "+strings.Join(notes, " • "))
+	}
+
+	hint := recordTypeStyle.Render(fmt.Sprintf("%s rows/SQL/reverse SQL • %s copy SQL • %d%%",
+		keys.DiffSQL.Help().Key, keys.Copy.Help().Key,
+		int(d.viewport.ScrollPercent()*100)))
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.appBoundaryView(title),
+		summary,
+		"",
+		d.viewport.View(),
+		hint,
+	)
+}
@@ -0,0 +1,330 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// how much of each table the diagram shows
+const (
+	erNames   = iota // table names only
+	erKeys           // primary and foreign key columns
+	erColumns        // every column with its type
+)
+
+var erZoomNames = []string{"names", "keys", "columns"}
+
+// database schema drawn as boxes, tables referenced by foreign keys are placed above
+// the tables referencing them
+type erPanel struct {
+	tables   []database.TableSchema
+	selected int // index in tables
+	zoom     int
+	top      int // first diagram line on screen
+	left     int // first diagram cell on screen
+	width    int
+	height   int
+}
+
+// a table box placed in the diagram
+type erBox struct {
+	table      int
+	x, y, w, h int
+}
+
+var (
+	erBoxStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+	erTitleStyle  = lipgloss.NewStyle().Bold(true)
+	erDimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	erSelected    = lipgloss.Color("212")
+	erParentColor = lipgloss.Color("42")
+	erChildColor  = lipgloss.Color("39")
+)
+
+func newERPanel() erPanel {
+	return erPanel{zoom: erColumns}
+}
+
+func (p *erPanel) setSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.scrollToSelected()
+}
+
+func (p *erPanel) setTables(tables []database.TableSchema) {
+	p.tables = tables
+	p.selected = min(p.selected, max(len(tables)-1, 0))
+	p.scrollToSelected()
+}
+
+func (p *erPanel) update(msg tea.KeyMsg) tea.Cmd {
+	if len(p.tables) == 0 {
+		return nil
+	}
+
+	switch {
+	case key.Matches(msg, keys.Up):
+		p.moveVertical(-1)
+	case key.Matches(msg, keys.Down):
+		p.moveVertical(1)
+	case key.Matches(msg, keys.Left):
+		p.moveHorizontal(-1)
+	case key.Matches(msg, keys.Right):
+		p.moveHorizontal(1)
+	case key.Matches(msg, keys.ZoomIn):
+		p.zoom = min(p.zoom+1, erColumns)
+		p.scrollToSelected()
+	case key.Matches(msg, keys.ZoomOut):
+		p.zoom = max(p.zoom-1, erNames)
+		p.scrollToSelected()
+	case key.Matches(msg, keys.ExportDOT):
+		return copyCmd("DOT diagram", database.SchemaDOT(p.tables))
+	case key.Matches(msg, keys.ExportMermaid):
+		return copyCmd("Mermaid diagram", database.SchemaMermaid(p.tables))
+	case key.Matches(msg, keys.Enter):
+		return selectTableCmd(p.tables[p.selected].Name)
+	}
+	return nil
+}
+
+// foreign key depth of each table: 0 if it references no other table,
+// else one more than its deepest parent. cycles stop growing at the table count
+func (p erPanel) levels() []int {
+	index := make(map[string]int, len(p.tables))
+	for i, t := range p.tables {
+		index[strings.ToLower(t.Name)] = i
+	}
+
+	levels := make([]int, len(p.tables))
+	for range p.tables {
+		changed := false
+		for i, t := range p.tables {
+			for _, fk := range t.ForeignKeys {
+				parent, ok := index[strings.ToLower(fk.RefTable)]
+				if !ok || parent == i {
+					continue
+				}
+				if l := levels[parent] + 1; l > levels[i] && l < len(p.tables) {
+					levels[i] = l
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return levels
+}
+
+// true if table a has a foreign key to table b
+func (p erPanel) references(a, b int) bool {
+	for _, fk := range p.tables[a].ForeignKeys {
+		if strings.EqualFold(fk.RefTable, p.tables[b].Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p erPanel) renderBox(i int) string {
+	t := p.tables[i]
+	lines := []string{erTitleStyle.Render(t.Name)}
+
+	for _, col := range t.Columns {
+		mark := "  "
+		switch {
+		case col.PK:
+			mark = "PK"
+		case col.RefTable != "":
+			mark = "FK"
+		}
+		if p.zoom == erNames || p.zoom == erKeys && mark == "  " {
+			continue
+		}
+
+		line := erDimStyle.Render(mark) + " " + col.Name
+		if p.zoom == erColumns && col.Type != "" {
+			line += " " + erDimStyle.Render(col.Type)
+		}
+		if col.RefTable != "" {
+			line += erDimStyle.Render(" → " + col.RefTable)
+		}
+		lines = append(lines, line)
+	}
+
+	style := erBoxStyle
+	switch {
+	case i == p.selected:
+		style = style.BorderForeground(erSelected)
+	case p.references(p.selected, i):
+		style = style.BorderForeground(erParentColor)
+	case p.references(i, p.selected):
+		style = style.BorderForeground(erChildColor)
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// places the boxes in one band per level, wrapping bands wider than the panel.
+// returns the boxes in reading order and the lines of the whole diagram
+func (p erPanel) layout() ([]erBox, []string) {
+	levels := p.levels()
+	width, _ := p.bodySize()
+	deepest := 0
+	for _, l := range levels {
+		deepest = max(deepest, l)
+	}
+
+	var boxes []erBox
+	var bands []string
+	var row []string
+	x, y := 0, 0
+	flush := func() {
+		if len(row) == 0 {
+			return
+		}
+		band := lipgloss.JoinHorizontal(lipgloss.Top, row...)
+		bands = append(bands, band)
+		y += lipgloss.Height(band) + 1
+		row = nil
+		x = 0
+	}
+
+	for level := 0; level <= deepest; level++ {
+		for i := range p.tables {
+			if levels[i] != level {
+				continue
+			}
+			box := p.renderBox(i)
+			w, h := lipgloss.Width(box), lipgloss.Height(box)
+			if x > 0 && x+w > width {
+				flush()
+			}
+			boxes = append(boxes, erBox{i, x, y, w, h})
+			row = append(row, box, "  ")
+			x += w + 2
+		}
+		flush()
+	}
+
+	return boxes, strings.Split(strings.Join(bands, "\n\n"), "\n")
+}
+
+func (p erPanel) selectedBox(boxes []erBox) int {
+	for n, b := range boxes {
+		if b.table == p.selected {
+			return n
+		}
+	}
+	return 0
+}
+
+// selects the previous (-1) or next (1) box in reading order
+func (p *erPanel) moveHorizontal(d int) {
+	boxes, _ := p.layout()
+	n := min(max(p.selectedBox(boxes)+d, 0), len(boxes)-1)
+	p.selected = boxes[n].table
+	p.scrollToSelected()
+}
+
+// selects the box in the band above (-1) or below (1) closest to the current one
+func (p *erPanel) moveVertical(d int) {
+	boxes, _ := p.layout()
+	cur := boxes[p.selectedBox(boxes)]
+	center := cur.x + cur.w/2
+
+	best := -1
+	for n, b := range boxes {
+		if d < 0 && b.y >= cur.y || d > 0 && b.y <= cur.y {
+			continue
+		}
+		if best < 0 {
+			best = n
+			continue
+		}
+		// nearest band first, then nearest column
+		nearer := d < 0 && b.y > boxes[best].y || d > 0 && b.y < boxes[best].y
+		sameBand := b.y == boxes[best].y
+		if nearer || sameBand && abs(b.x+b.w/2-center) < abs(boxes[best].x+boxes[best].w/2-center) {
+			best = n
+		}
+	}
+	if best >= 0 {
+		p.selected = boxes[best].table
+		p.scrollToSelected()
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// room for the diagram inside the border, title and hint
+func (p erPanel) bodySize() (int, int) {
+	return max(p.width-4, 10), max(p.height-5, 3)
+}
+
+// scrolls so the selected box is on screen, its top left corner if it does not fit
+func (p *erPanel) scrollToSelected() {
+	if len(p.tables) == 0 {
+		return
+	}
+	boxes, _ := p.layout()
+	b := boxes[p.selectedBox(boxes)]
+	width, height := p.bodySize()
+
+	if b.y+b.h > p.top+height {
+		p.top = b.y + b.h - height
+	}
+	p.top = min(p.top, b.y)
+	if b.x+b.w > p.left+width {
+		p.left = b.x + b.w - width
+	}
+	p.left = min(p.left, b.x)
+}
+
+func (p erPanel) View() string {
+	width, height := p.bodySize()
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170")).
+		Render(fmt.Sprintf("Schema (%d tables)", len(p.tables)))
+
+	body := "No tables"
+	if len(p.tables) > 0 {
+		_, lines := p.layout()
+		lines = lines[min(p.top, len(lines)):min(p.top+height, len(lines))]
+		for i, line := range lines {
+			lines[i] = ansi.Cut(line, p.left, p.left+width)
+		}
+		body = strings.Join(lines, "\n")
+	}
+
+	hint := erDimStyle.Render(fmt.Sprintf("zoom: %s (%s/%s) • %s copy DOT • %s copy Mermaid • enter open • ",
+		erZoomNames[p.zoom], keys.ZoomIn.Help().Key, keys.ZoomOut.Help().Key,
+		keys.ExportDOT.Help().Key, keys.ExportMermaid.Help().Key)) +
+		lipgloss.NewStyle().Foreground(erParentColor).Render("referenced") + erDimStyle.Render(" / ") +
+		lipgloss.NewStyle().Foreground(erChildColor).Render("referencing")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlightColor).
+		Width(p.width - 2).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			title,
+			lipgloss.NewStyle().Height(height).Render(body),
+			hint,
+		))
+}
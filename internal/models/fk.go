@@ -0,0 +1,189 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+const (
+	maxBackStack  = 50
+	maxRefOptions = 1000 // rows offered in a foreign key picker
+)
+
+// where the Data tab was before following a foreign key
+type navEntry struct {
+	table  string
+	filter string
+	row    int
+	column int
+}
+
+// child rows pointing at the selected row through one foreign key
+type reference struct {
+	fk    database.ForeignKey
+	where string
+	count int
+}
+
+// values of the named columns in row
+func rowValues(columns []database.Column, row []any, names []string) []any {
+	values := make([]any, len(names))
+	for i, name := range names {
+		for j, col := range columns {
+			if strings.EqualFold(col.Name, name) {
+				values[i] = row[j]
+			}
+		}
+	}
+	return values
+}
+
+// remembers the current row so backspace can return to it
+func (m *model) pushBack() {
+	entry := navEntry{m.name, m.filter, m.dataTable.Cursor(), 0}
+	if l := m.layouts[m.name]; l != nil {
+		entry.column = l.focused
+	}
+	m.back = append(m.back, entry)
+	if len(m.back) > maxBackStack {
+		m.back = m.back[1:]
+	}
+}
+
+// loads the parent row referenced by the foreign key in the focused cell
+func (m *model) followRef() tea.Cmd {
+	row := m.selectedData()
+	if row == nil {
+		return nil
+	}
+	col := m.columns[m.layout().focused]
+	if col.RefTable == "" {
+		return errCmd(fmt.Errorf("%s is not a foreign key", col.Name))
+	}
+	if row[m.layout().focused] == nil {
+		return errCmd(fmt.Errorf("%s is NULL", col.Name))
+	}
+
+	m.pushBack()
+	return followRefCmd(m.store, m.name, m.columns, row, col.Name)
+}
+
+// goes back to the table and row shown before the last jump
+func (m *model) goBack() tea.Cmd {
+	if len(m.back) == 0 {
+		return statusCmd("Nothing to go back to")
+	}
+	entry := m.back[len(m.back)-1]
+	m.back = m.back[:len(m.back)-1]
+	m.restore = &entry
+	return loadTableDataCmd(m.store, entry.table, entry.filter, 0)
+}
+
+// puts the cursor back where it was after going back
+func (m *model) restorePosition() {
+	entry := m.restore
+	m.restore = nil
+	if entry == nil || entry.table != m.name || len(m.rows) == 0 {
+		return
+	}
+
+	m.dataTable.SetCursor(min(entry.row, len(m.rows)-1))
+	m.dataTop = scrollTop(0, m.dataTable.Cursor(), m.dataTable.Height())
+	if entry.column < len(m.columns) {
+		m.layout().focused = entry.column
+		m.applyLayout()
+	}
+}
+
+// opens a select of the tables referencing the selected row, with their row counts
+func (m *model) openReferences(refs []reference) tea.Cmd {
+	if len(refs) == 0 {
+		return statusCmd("No foreign keys reference %s", m.name)
+	}
+
+	options := make([]huh.Option[int], len(refs))
+	for i, ref := range refs {
+		rows := "rows"
+		if ref.count == 1 {
+			rows = "row"
+		}
+		label := fmt.Sprintf("%s.%s (%d %s)", ref.fk.Table, strings.Join(ref.fk.Columns, ", "), ref.count, rows)
+		options[i] = huh.NewOption(label, i)
+	}
+
+	m.openTab(refsTab, "Referenced By")
+	m.refs = refs
+	m.refChoice = new(int)
+	m.picker = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Rows referencing this " + m.name + " row").
+				Description("enter shows the rows, esc cancels").
+				Options(options...).
+				Height(min(len(options)+2, max(m.dataTable.Height(), 5))).
+				Value(m.refChoice),
+		),
+	).WithWidth(max(m.width-4, 30)).WithShowHelp(false)
+	return m.picker.Init()
+}
+
+// shows the child rows of the picked reference
+func (m *model) showReference() tea.Cmd {
+	ref := m.refs[*m.refChoice]
+	m.closeTab(dataTab)
+	m.pushBack()
+	return loadTableDataCmd(m.store, ref.fk.Table, ref.where, 0)
+}
+
+// searchable select of the rows of the referenced table, showing their label but storing the key.
+// the rows are loaded when the form starts
+func (m *model) refSelect(col database.Column, titleFn func() string, isNull *bool, value *string) huh.Field {
+	store := m.store
+	current := *value
+	none := "(default)"
+	if m.formMode == editForm {
+		none = "(none)"
+	}
+
+	options := func() []huh.Option[string] {
+		refs, err := store.GetRefOptions(col.RefTable, col.RefColumn, maxRefOptions)
+
+		var opts []huh.Option[string]
+		found := false
+		for _, ref := range refs {
+			key := database.EditValue(ref.Key)
+			label := key
+			if ref.Label != "" {
+				label = fmt.Sprintf("%s (%s)", ref.Label, key)
+			}
+			found = found || key == current
+			opts = append(opts, huh.NewOption(label, key))
+		}
+
+		// the current value stays selectable even if it is missing or past the limit
+		if !found {
+			label := current
+			switch {
+			case err != nil:
+				label = fmt.Sprintf("%s (%s)", current, err)
+			case current == "":
+				label = none
+			}
+			opts = append([]huh.Option[string]{huh.NewOption(label, current)}, opts...)
+		}
+		return opts
+	}
+
+	return huh.NewSelect[string]().
+		Key(col.Name).
+		TitleFunc(titleFn, isNull).
+		Description("/ searches "+col.RefTable).
+		OptionsFunc(options, col.RefTable).
+		Height(8).
+		Value(value)
+}
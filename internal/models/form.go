@@ -3,9 +3,14 @@ package models
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"dbtui/internal/database"
+
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -19,7 +24,7 @@ func (m *model) formView() string {
 		form := lipgloss.NewStyle().Render(v)
 
 		errors := m.form.Errors()
-		header := m.appBoundaryView("Edit Entry")
+		header := m.appBoundaryView(m.formMode.title())
 		if len(errors) > 0 {
 			header = m.appErrorBoundaryView(m.errorView())
 		}
@@ -27,58 +32,379 @@ func (m *model) formView() string {
 	}
 }
 
-func (m *model) onEditSuccess(t tab) {
-	m.tabs = nil
-	m.tabs = []string{"Data", "Info", "Query"}
-	m.activeTab = t
-	m.selectedRow = nil
-	m.toEdit = nil
-	m.form = nil
+type formMode int
+
+const (
+	editForm formMode = iota
+	insertForm
+	deleteForm
+)
+
+func (f formMode) title() string {
+	switch f {
+	case insertForm:
+		return "Insert Entry"
+	case deleteForm:
+		return "Delete Entry"
+	default:
+		return "Edit Entry"
+	}
 }
 
-func (m *model) onRowSelect(row []string) {
+func (m *model) onRowSelect(row []any) {
 	m.selectedRow = row
+	m.formMode = editForm
 
-	m.tabs = append(m.tabs, "Edit")
-	m.activeTab = editTab
+	m.openTab(editTab, "Edit")
 
-	m.toEdit = row
+	// the loaded row is kept for finding it by key and detecting changes
+	m.toEdit = make([]string, len(row))
+	m.toggles = make(map[int]*bool)
+	m.nulls = make(map[int]*bool)
 
 	var inputs []huh.Field
 	for i, col := range m.columns {
-		inputs = append(
-			inputs,
-			huh.NewInput().
-				Key(col.Name).
-				Title(fmt.Sprintf("%s: (%s)", col.Name, col.Type)).
-				// Description(col.Type).
-				Placeholder(row[i]).
-				Value(&m.toEdit[i]).
-				Validate(func(str string) error {
-					switch col.Type {
-					case "TEXT":
-						return nil
-					case "INTEGER":
-						if str == "NULL" {
-							return nil
-						}
-						if _, err := strconv.Atoi(str); err != nil {
-							return errors.New("Not an integer")
-						}
-					default:
-						return nil
-					}
-					return nil
-				}),
-		)
-	}
-	inputs = append(inputs, huh.NewConfirm().Title("Save").Value(&m.confirmEdit))
+		m.toEdit[i] = database.EditValue(row[i])
+		isNull := row[i] == nil
+		m.nulls[i] = &isNull
+		if _, ok := row[i].([]byte); ok || col.IsBlob() {
+			inputs = append(inputs, m.blobNote(col, row[i]))
+			continue
+		}
+		inputs = append(inputs, m.newField(i, col, m.toEdit[i]))
+	}
+	m.confirmEdit = new(bool)
+	inputs = append(inputs, huh.NewConfirm().Title("Save").Value(m.confirmEdit))
 
 	m.form = huh.NewForm(
 		huh.NewGroup(inputs...),
 	).WithWidth(45)
 }
 
+func (m *model) onInsert() {
+	m.selectedRow = nil
+	m.formMode = insertForm
+
+	m.openTab(editTab, "Insert")
+
+	m.toEdit = make([]string, len(m.columns))
+	m.toggles = make(map[int]*bool)
+	m.nulls = make(map[int]*bool)
+
+	var inputs []huh.Field
+	for i, col := range m.columns {
+		isNull := false
+		m.nulls[i] = &isNull
+		if col.IsBlob() {
+			inputs = append(inputs, m.blobNote(col, nil))
+			continue
+		}
+
+		def := ""
+		if col.DefaultValue != nil && (col.IsBool() || len(col.Enum) > 0) {
+			def = strings.Trim(*col.DefaultValue, "'")
+		}
+		if len(col.Enum) > 0 {
+			m.toEdit[i] = def
+		}
+		inputs = append(inputs, m.newField(i, col, def))
+	}
+	m.confirmEdit = new(bool)
+	inputs = append(inputs, huh.NewConfirm().Title("Insert").Value(m.confirmEdit))
+
+	m.form = huh.NewForm(
+		huh.NewGroup(inputs...),
+	).WithWidth(45)
+}
+
+// picks a widget for the column from its affinity and constraints
+func (m *model) newField(i int, col database.Column, value string) huh.Field {
+	isNull := m.nulls[i]
+	titleFn := func() string {
+		title := fmt.Sprintf("%s: (%s)", col.Name, col.Type)
+		if *isNull {
+			title += " " + database.NullDisplay + " NULL"
+		}
+		return title
+	}
+
+	placeholder := value
+	if m.formMode == insertForm {
+		placeholder = col.Type
+		if col.DefaultValue != nil {
+			placeholder = "default " + *col.DefaultValue
+		}
+	}
+
+	switch {
+	case col.Generated != "":
+		// SQLite computes the value, writing it fails
+		desc := value
+		switch {
+		case m.formMode == insertForm:
+			desc = "computed from the other columns"
+		case m.selectedRow[i] == nil:
+			desc = database.NullDisplay + " NULL"
+		}
+		return huh.NewNote().
+			Title(fmt.Sprintf("%s: (%s, generated %s)", col.Name, col.Type, strings.ToLower(col.Generated))).
+			Description(desc)
+
+	case col.RefTable != "":
+		return m.refSelect(col, titleFn, isNull, &m.toEdit[i])
+
+	case len(col.Enum) > 0:
+		var options []huh.Option[string]
+		if !slices.Contains(col.Enum, value) {
+			label := value
+			if value == "" {
+				label = "(default)"
+			}
+			options = append(options, huh.NewOption(label, value))
+		}
+		for _, v := range col.Enum {
+			options = append(options, huh.NewOption(v, v))
+		}
+
+		return huh.NewSelect[string]().
+			Key(col.Name).
+			TitleFunc(titleFn, isNull).
+			Options(options...).
+			Value(&m.toEdit[i])
+
+	case col.IsBool():
+		on := value == "1" || strings.EqualFold(value, "true")
+		m.toggles[i] = &on
+
+		return huh.NewConfirm().
+			Key(col.Name).
+			TitleFunc(titleFn, isNull).
+			Affirmative("true").
+			Negative("false").
+			Value(m.toggles[i])
+
+	case col.Affinity() == "TEXT" && (len(value) > 40 || strings.Contains(value, "\n")):
+		return huh.NewText().
+			Key(col.Name).
+			TitleFunc(titleFn, isNull).
+			Lines(4).
+			CharLimit(0).
+			Value(&m.toEdit[i])
+	}
+
+	input := huh.NewInput().
+		Key(col.Name).
+		TitleFunc(titleFn, isNull).
+		Placeholder(placeholder).
+		Value(&m.toEdit[i])
+
+	// NULL fields skip validation, their text is not written
+	skipNull := func(validate func(string) error) func(string) error {
+		return func(str string) error {
+			if *isNull {
+				return nil
+			}
+			return validate(str)
+		}
+	}
+
+	switch {
+	case col.IsTime():
+		input.Description("YYYY-MM-DD [HH:MM:SS] or now").Validate(skipNull(validateTime))
+	case col.Affinity() == "INTEGER":
+		input.Validate(skipNull(validateInteger))
+	case col.Affinity() == "REAL" || isDecimal(col):
+		input.Validate(skipNull(validateNumber))
+	}
+
+	return input
+}
+
+// blobs can't be typed, showing them as text would write the display string back
+func (m *model) blobNote(col database.Column, value any) huh.Field {
+	var desc string
+	switch v := value.(type) {
+	case nil:
+		desc = database.NullDisplay + " NULL • set from a file with the blob inspector (b)"
+		if m.formMode == insertForm {
+			desc = "set from a file with the blob inspector (b) after inserting"
+		}
+	case []byte:
+		desc = fmt.Sprintf("%s, %s • edit with the blob inspector (b)", database.FormatBytes(len(v)), detectContent(v).name)
+	default:
+		desc = database.FormatValue(v) + " • edit with the blob inspector (b)"
+	}
+	return huh.NewNote().
+		Title(fmt.Sprintf("%s: (%s)", col.Name, col.Type)).
+		Description(desc)
+}
+
+// sets the focused field to NULL or back
+func (m *model) toggleNull() error {
+	field := m.form.GetFocusedField()
+	if field == nil {
+		return nil
+	}
+
+	for i, col := range m.columns {
+		if col.Name != field.GetKey() {
+			continue
+		}
+		if col.NotNull || col.PK {
+			return fmt.Errorf("%s is NOT NULL", col.Name)
+		}
+		*m.nulls[i] = !*m.nulls[i]
+		return nil
+	}
+	return nil
+}
+
+// builds the row to write from the form fields
+func (m *model) formValues() []any {
+	m.collectValues()
+
+	vals := make([]any, len(m.columns))
+	for i, col := range m.columns {
+		_, isToggle := m.toggles[i]
+
+		switch {
+		case *m.nulls[i]:
+			vals[i] = nil
+		case m.formMode == editForm && isBytes(m.selectedRow[i]):
+			// blobs are only changed through the blob inspector
+			vals[i] = m.selectedRow[i]
+		case m.formMode == insertForm && m.toEdit[i] == "" && !isToggle:
+			vals[i] = database.Default
+		case m.formMode == editForm && m.selectedRow[i] != nil &&
+			m.toEdit[i] == database.EditValue(m.selectedRow[i]):
+			// unchanged, keep the original value and type
+			vals[i] = m.selectedRow[i]
+		default:
+			vals[i] = database.ParseValue(col.Type, m.toEdit[i])
+		}
+	}
+	return vals
+}
+
+func isBytes(v any) bool {
+	_, ok := v.([]byte)
+	return ok
+}
+
+// copies widget values that are not bound to toEdit directly
+func (m *model) collectValues() {
+	for i, on := range m.toggles {
+		m.toEdit[i] = "0"
+		if *on {
+			m.toEdit[i] = "1"
+		}
+	}
+
+	for i, col := range m.columns {
+		if col.IsTime() && strings.EqualFold(strings.TrimSpace(m.toEdit[i]), "now") {
+			m.toEdit[i] = nowValue(col)
+		}
+	}
+}
+
+var timeLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"15:04",
+	"15:04:05",
+}
+
+// declared DECIMAL or NUMERIC. other types SQLite does not know, ex. JSON or
+// UUID, also get NUMERIC affinity but hold text
+func isDecimal(col database.Column) bool {
+	t := strings.ToUpper(col.Type)
+	return strings.Contains(t, "DECIMAL") || strings.Contains(t, "NUMERIC")
+}
+
+func validateTime(str string) error {
+	str = strings.TrimSpace(str)
+	if str == "" || strings.EqualFold(str, "now") {
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if _, err := time.Parse(layout, str); err == nil {
+			return nil
+		}
+	}
+	return errors.New("Not a date/time (YYYY-MM-DD HH:MM:SS)")
+}
+
+func validateInteger(str string) error {
+	if str == "" {
+		return nil
+	}
+	if _, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64); err != nil {
+		return errors.New("Not an integer")
+	}
+	return nil
+}
+
+func validateNumber(str string) error {
+	if str == "" {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err != nil {
+		return errors.New("Not a number")
+	}
+	return nil
+}
+
+// current time in the format matching the declared type
+func nowValue(col database.Column) string {
+	now := time.Now().UTC()
+	t := strings.ToUpper(col.Type)
+	switch {
+	case strings.Contains(t, "DATETIME"), strings.Contains(t, "TIMESTAMP"):
+		return now.Format("2006-01-02 15:04:05")
+	case strings.Contains(t, "DATE"):
+		return now.Format("2006-01-02")
+	default:
+		return now.Format("15:04:05")
+	}
+}
+
+func (m *model) onDelete(row []any) {
+	m.selectedRow = row
+	m.formMode = deleteForm
+
+	m.openTab(editTab, "Delete")
+
+	var b strings.Builder
+	for i, col := range m.columns {
+		fmt.Fprintf(&b, "%s: %s\n", col.Name, database.FormatValue(row[i]))
+	}
+
+	m.confirmEdit = new(bool)
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().Title("Row").Description(b.String()),
+			huh.NewConfirm().Title("Delete this row?").Value(m.confirmEdit),
+		),
+	).WithWidth(45)
+}
+
+// returns the command that writes the completed form
+func (m *model) submitCmd() tea.Cmd {
+	switch m.formMode {
+	case insertForm:
+		return insertSubmitCmd(m.name, m.columns, m.formValues())
+	case deleteForm:
+		return deleteSubmitCmd(m.name, m.columns, m.selectedRow)
+	default:
+		return editSubmitCmd(m.name, m.columns, m.selectedRow, m.formValues())
+	}
+}
+
 func (m model) errorView() string {
 	var s string
 	for _, err := range m.form.Errors() {
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dbtui/internal/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// values bound to the generate rows form
+type generateDraft struct {
+	rows    string
+	seed    string
+	confirm bool
+}
+
+// options of the completed form, false if a field does not parse
+func (d *generateDraft) options() (database.GenerateOptions, bool) {
+	rows, err := strconv.Atoi(strings.TrimSpace(d.rows))
+	if err != nil || rows <= 0 {
+		return database.GenerateOptions{}, false
+	}
+	var seed int64
+	if s := strings.TrimSpace(d.seed); s != "" {
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return database.GenerateOptions{}, false
+		}
+	}
+	return database.GenerateOptions{Rows: rows, Seed: seed}, true
+}
+
+// opens the form adding generated rows to the table
+func (m *model) openGenerate() tea.Cmd {
+	draft := &generateDraft{rows: "10"}
+	tableName := m.name
+
+	m.openTab(generateTab, "Generate")
+	m.generate = draft
+	m.generateForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Rows").
+				Description("values are guessed from column names and types, empty tables it references are filled too").
+				Validate(func(s string) error {
+					if n, err := strconv.Atoi(strings.TrimSpace(s)); err != nil || n <= 0 {
+						return fmt.Errorf("Enter a positive number")
+					}
+					return nil
+				}).
+				Value(&draft.rows),
+			huh.NewInput().
+				Title("Seed").
+				Description("the same seed gives the same rows, leave empty for a random one").
+				Validate(func(s string) error {
+					if s = strings.TrimSpace(s); s == "" {
+						return nil
+					}
+					if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+						return fmt.Errorf("Enter a whole number")
+					}
+					return nil
+				}).
+				Value(&draft.seed),
+		),
+		huh.NewGroup(
+			huh.NewConfirm().
+				TitleFunc(func() string {
+					return fmt.Sprintf("Insert %s rows into %s?", strings.TrimSpace(draft.rows), tableName)
+				}, draft).
+				Value(&draft.confirm),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.generateForm.Init()
+}
+
+// command of the completed form, nil if it was not confirmed
+func (m *model) generateDone() tea.Cmd {
+	d := m.generate
+	if d == nil || !d.confirm {
+		return nil
+	}
+	opts, ok := d.options()
+	if !ok {
+		return nil
+	}
+	return generateCmd(m.store, m.name, opts)
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	gridHeaderStyle = lipgloss.NewStyle().Padding(0, 1)
+	gridFocusStyle  = gridHeaderStyle.Foreground(lipgloss.Color("212")).Bold(true)
+	gridBorderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	gridCellStyle   = lipgloss.NewStyle().Padding(0, 1)
+	gridNullStyle   = gridCellStyle.Foreground(lipgloss.Color("241"))
+	gridRowStyle    = gridCellStyle.Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57"))
+	gridCursorStyle = gridCellStyle.Foreground(lipgloss.Color("57")).Background(lipgloss.Color("229")).Bold(true)
+	gridMoreStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+
+	cellReplacer = strings.NewReplacer("\r\n", "↵", "\n", "↵", "\r", "", "\t", " ")
+)
+
+// draws the rows of t with the cell under the cursor highlighted.
+// t only keeps the cursor and the visible cells, raw has every value to tell NULL apart
+func renderGrid(t table.Model, raw [][]any, visible []int, focused, top int) string {
+	cols := t.Columns()
+	rows := t.Rows()
+	cursor := t.Cursor()
+	height := t.Height()
+	top = scrollTop(top, cursor, height)
+
+	var b strings.Builder
+	total := 0
+	for n, c := range cols {
+		style := gridHeaderStyle
+		if visible[n] == focused {
+			style = gridFocusStyle
+		}
+		b.WriteString(style.Render(fitCell(c.Title, c.Width)))
+		total += c.Width + 2
+	}
+	b.WriteString("\n")
+	b.WriteString(gridBorderStyle.Render(strings.Repeat("─", total)))
+
+	for r := top; r < top+height; r++ {
+		b.WriteString("\n")
+		if r >= len(rows) {
+			continue
+		}
+
+		for n, c := range cols {
+			style := gridCellStyle
+			switch {
+			case r == cursor && visible[n] == focused:
+				style = gridCursorStyle
+			case r == cursor:
+				style = gridRowStyle
+			case raw[r][visible[n]] == nil:
+				style = gridNullStyle
+			}
+			b.WriteString(style.Render(fitCell(rows[r][n], c.Width)))
+		}
+	}
+
+	return b.String()
+}
+
+// keeps the cursor row on screen, moving the first row as little as possible
+func scrollTop(top, cursor, height int) int {
+	switch {
+	case cursor < top:
+		return max(cursor, 0)
+	case cursor >= top+height:
+		return cursor - height + 1
+	}
+	return top
+}
+
+// cuts or pads a value to exactly width cells, on one line
+func fitCell(s string, width int) string {
+	s = ansi.Truncate(cellReplacer.Replace(s), width, "…")
+	return s + strings.Repeat(" ", max(width-lipgloss.Width(s), 0))
+}
+
+// shows how many columns are scrolled off each side
+func offscreenView(left, right, width int) string {
+	if left == 0 && right == 0 {
+		return ""
+	}
+
+	var l, r string
+	if left > 0 {
+		l = gridMoreStyle.Render(fmt.Sprintf("◂ %d more", left))
+	}
+	if right > 0 {
+		r = gridMoreStyle.Render(fmt.Sprintf("%d more ▸", right))
+	}
+	gap := max(width-lipgloss.Width(l)-lipgloss.Width(r), 1)
+	return l + strings.Repeat(" ", gap) + r
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	sqlKeywordStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	sqlStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	sqlNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	sqlCommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	sqlQuotedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+)
+
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`ABORT ACTION ADD AFTER ALL ALTER ALWAYS AND AS ASC AUTOINCREMENT
+		BEFORE BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLLATE COLUMN CONFLICT CONSTRAINT CREATE
+		CROSS CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP DEFAULT DEFERRABLE DEFERRED DELETE DESC
+		DISTINCT DROP EACH ELSE END ESCAPE EXCEPT EXISTS FAIL FOR FOREIGN FROM GENERATED GLOB GROUP
+		HAVING IF IGNORE IMMEDIATE IN INDEX INITIALLY INNER INSERT INSTEAD INTERSECT INTO IS ISNULL
+		JOIN KEY LEFT LIKE LIMIT MATCH NEW NO NOT NOTNULL NULL OF OFFSET OLD ON OR ORDER OUTER PRIMARY
+		RAISE RECURSIVE REFERENCES REGEXP REPLACE RESTRICT RETURNING RIGHT ROLLBACK ROW ROWID SELECT
+		SET STORED STRICT TABLE TEMP TEMPORARY THEN TO TRIGGER UNION UNIQUE UPDATE USING VALUES VIEW
+		VIRTUAL WHEN WHERE WITH WITHOUT`) {
+		sqlKeywords[w] = true
+	}
+}
+
+// colours the keywords, literals and comments of a SQL statement, line breaks are kept
+func highlightSQL(sql string) string {
+	var b strings.Builder
+	// styles are applied per line so the result can be split into lines
+	write := func(style lipgloss.Style, s string) {
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if line != "" {
+				b.WriteString(style.Render(line))
+			}
+		}
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		j := i + 1
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			for j < len(sql) && sql[j] != '\n' {
+				j++
+			}
+			write(sqlCommentStyle, sql[i:j])
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			j = len(sql)
+			if end >= 0 {
+				j = i + 2 + end + 2
+			}
+			write(sqlCommentStyle, sql[i:j])
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			for j < len(sql) {
+				if sql[j] == closing {
+					// doubled quotes are escapes
+					if closing != ']' && j+1 < len(sql) && sql[j+1] == closing {
+						j += 2
+						continue
+					}
+					j++
+					break
+				}
+				j++
+			}
+			style := sqlQuotedStyle
+			if c == '\'' {
+				style = sqlStringStyle
+			}
+			write(style, sql[i:j])
+		case isWordByte(c):
+			for j < len(sql) && isWordByte(sql[j]) {
+				j++
+			}
+			word := sql[i:j]
+			switch {
+			case sqlKeywords[strings.ToUpper(word)]:
+				write(sqlKeywordStyle, word)
+			case c >= '0' && c <= '9':
+				write(sqlNumberStyle, word)
+			default:
+				b.WriteString(word)
+			}
+		default:
+			b.WriteByte(c)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// values bound to the create and drop index forms
+type indexDraft struct {
+	columns string
+	unique  bool
+	where   string
+	name    string
+	drop    string // index to drop
+	confirm bool
+}
+
+// index described by the create form, nil if its columns don't parse
+func (d *indexDraft) index(tableName string, columns []database.Column) *database.Index {
+	cols, err := database.ParseIndexColumns(d.columns, columns)
+	if err != nil {
+		return nil
+	}
+
+	name := strings.TrimSpace(d.name)
+	if name == "" {
+		name = "idx_" + tableName
+		for _, col := range cols {
+			name += "_" + col.Name
+		}
+	}
+	return &database.Index{Name: name, Unique: d.unique, Where: d.where, Columns: cols}
+}
+
+func (m *model) setIndexTable() {
+	m.indexTable = newTable()
+	m.indexTable.SetColumns([]table.Column{
+		{Title: "Name", Width: 24},
+		{Title: "Unique", Width: 6},
+		{Title: "Columns", Width: 30},
+		{Title: "Where", Width: 20},
+		{Title: "Origin", Width: 8},
+	})
+
+	origins := map[string]string{"c": "index", "u": "unique", "pk": "key"}
+	rows := make([]table.Row, len(m.indexes))
+	for i, idx := range m.indexes {
+		rows[i] = table.Row{
+			idx.Name,
+			fmt.Sprintf("%v", idx.Unique),
+			idx.ColumnList(),
+			idx.Where,
+			origins[idx.Origin],
+		}
+	}
+	m.indexTable.SetRows(rows)
+	m.indexTable.SetHeight(min(max(len(rows), 1)+2, 6))
+	m.indexTable.Blur()
+}
+
+// opens the create index form, the statement is shown before it runs
+func (m *model) openCreateIndex() tea.Cmd {
+	draft := &indexDraft{}
+	tableName := m.name
+	columns := m.columns
+	store := m.store
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+
+	m.openTab(indexTab, "Index")
+	m.index = draft
+	m.indexForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Columns").
+				Description("in index order, add DESC for descending: "+strings.Join(names, ", ")).
+				Suggestions(names).
+				Validate(func(s string) error {
+					_, err := database.ParseIndexColumns(s, columns)
+					return err
+				}).
+				Value(&draft.columns),
+			huh.NewConfirm().
+				Title("Unique").
+				Value(&draft.unique),
+			huh.NewInput().
+				Title("Where").
+				Description("makes a partial index, leave empty to index every row").
+				Value(&draft.where),
+			huh.NewInput().
+				Title("Name").
+				PlaceholderFunc(func() string {
+					if idx := draft.index(tableName, columns); idx != nil {
+						return idx.Name
+					}
+					return "idx_" + tableName
+				}, draft).
+				Value(&draft.name),
+		),
+		huh.NewGroup(
+			huh.NewNote().
+				Title("SQL").
+				DescriptionFunc(func() string {
+					if idx := draft.index(tableName, columns); idx != nil {
+						return noteText(store.CreateIndexSQL(*idx, tableName))
+					}
+					return ""
+				}, draft),
+			huh.NewConfirm().
+				Title("Create index?").
+				Value(&draft.confirm),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.indexForm.Init()
+}
+
+// opens a select of the indexes that can be dropped
+func (m *model) openDropIndex() tea.Cmd {
+	var options []huh.Option[string]
+	for _, idx := range m.indexes {
+		if idx.Droppable() {
+			options = append(options, huh.NewOption(idx.Name+" ("+idx.ColumnList()+")", idx.Name))
+		}
+	}
+	if len(options) == 0 {
+		return statusCmd("%s has no indexes made with CREATE INDEX", m.name)
+	}
+
+	draft := &indexDraft{}
+	store, tableName := m.store, m.name
+	m.openTab(indexTab, "Index")
+	m.index = draft
+	m.indexForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Drop index").
+				Options(options...).
+				Value(&draft.drop),
+			huh.NewNote().
+				Title("SQL").
+				DescriptionFunc(func() string {
+					return noteText(store.DropIndexSQL(draft.drop, tableName))
+				}, draft),
+			huh.NewConfirm().
+				Title("Drop index?").
+				Value(&draft.confirm),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.indexForm.Init()
+}
+
+var noteReplacer = strings.NewReplacer(`\`, `\\`, "_", `\_`, "*", `\*`, "`", "\\`")
+
+// escapes the markup of huh notes, ex. _ starts italics
+func noteText(s string) string {
+	return noteReplacer.Replace(s)
+}
+
+// statement of the completed form, "" if it was not confirmed
+func (m *model) indexStatement() string {
+	d := m.index
+	if d == nil || !d.confirm {
+		return ""
+	}
+	if d.drop != "" {
+		return m.store.DropIndexSQL(d.drop, m.name)
+	}
+	if idx := d.index(m.name, m.columns); idx != nil {
+		return m.store.CreateIndexSQL(*idx, m.name)
+	}
+	return ""
+}
@@ -2,9 +2,11 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func (m *model) infoView() string {
@@ -12,16 +14,52 @@ func (m *model) infoView() string {
 		return "No table selected"
 	}
 
-	title := lipgloss.NewStyle().
+	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("170")).
-		Render(fmt.Sprintf("Table: %s (Page %d)", m.name, m.currentPage+1))
+		Foreground(lipgloss.Color("170"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	title := titleStyle.Render(fmt.Sprintf("Table: %s (Page %d)", m.name, m.currentPage+1))
+	if flags := m.tableFlags(); flags != "" {
+		title += "  " + dimStyle.Render(flags)
+	}
+
+	if m.showDDL {
+		lines := m.ddlLines()
+		height := m.ddlHeight()
+		top := min(m.ddlTop, max(len(lines)-height, 0))
+		lines = lines[top:min(top+height, len(lines))]
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, max(m.width-8, 10), "…")
+		}
+
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			title,
+			"",
+			lipgloss.NewStyle().Height(height).Render(strings.Join(lines, "\n")),
+			"",
+			dimStyle.Render(fmt.Sprintf("%s back to columns • ↑/↓ scroll", keys.DDL.Help().Key)),
+		)
+	}
+
+	hint := dimStyle.Render(fmt.Sprintf("%s %s • %s %s • %s %s • %s %s • %s %s",
+		keys.NewIndex.Help().Key, keys.NewIndex.Help().Desc,
+		keys.DropIndex.Help().Key, keys.DropIndex.Help().Desc,
+		keys.EditTrigger.Help().Key, keys.EditTrigger.Help().Desc,
+		keys.DropTrigger.Help().Key, keys.DropTrigger.Help().Desc,
+		keys.DDL.Help().Key, keys.DDL.Help().Desc))
 
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		title,
 		"",
 		baseStyle.Render(m.infoTable.View()),
+		"",
+		titleStyle.Render(fmt.Sprintf("Indexes (%d)", len(m.indexes))),
+		baseStyle.Render(m.indexTable.View()),
+		titleStyle.Render(fmt.Sprintf("Triggers (%d)", len(m.triggers))),
+		baseStyle.Render(m.triggerTable.View()),
+		hint,
 	)
 }
 
@@ -29,22 +67,40 @@ func (m *model) setInfoTable() {
 	m.infoTable = newTable()
 
 	tableCols := []table.Column{
-		{Title: "CID", Width: 5},
-		{Title: "Name", Width: 15},
+		{Title: "CID", Width: 3},
+		{Title: "Name", Width: 14},
 		{Title: "Type", Width: 10},
-		{Title: "NotNull", Width: 10},
-		{Title: "DefaultValue", Width: 15},
+		{Title: "NotNull", Width: 7},
+		{Title: "DefaultValue", Width: 12},
 		{Title: "PK", Width: 5},
+		{Title: "Unique", Width: 6},
+		{Title: "Collate", Width: 7},
+		{Title: "Generated", Width: 9},
+		{Title: "References", Width: 15},
 	}
 
 	m.infoTable.SetColumns(tableCols)
-	
-	rows := make([]table.Row, len(m.columns))
-	for i, col := range m.columns {
+
+	// hidden columns are only in the definition
+	columns := m.def.Columns
+	if len(columns) == 0 {
+		columns = m.columns
+	}
+
+	rows := make([]table.Row, len(columns))
+	for i, col := range columns {
 		defStr := "NULL"
 		if col.DefaultValue != nil {
 			defStr = *col.DefaultValue
 		}
+		ref := ""
+		if col.RefTable != "" {
+			ref = col.RefTable + "." + col.RefColumn
+		}
+		generated := col.Generated
+		if col.Hidden {
+			generated = "hidden"
+		}
 		rows[i] = []string{
 			fmt.Sprintf("%d", col.CID),
 			col.Name,
@@ -52,9 +108,50 @@ func (m *model) setInfoTable() {
 			fmt.Sprintf("%v", col.NotNull),
 			defStr,
 			fmt.Sprintf("%v", col.PK),
+			fmt.Sprintf("%v", col.Unique),
+			col.Collate,
+			generated,
+			ref,
 		}
 	}
 	m.infoTable.SetRows(rows)
+	m.infoTable.SetHeight(min(len(rows)+2, 10))
 	m.infoTable.GotoTop()
 	m.infoTable.Focus()
+	m.setIndexTable()
+	m.setTriggerTable()
+}
+
+// kind of table and the options after its column list, ex. WITHOUT ROWID
+func (m *model) tableFlags() string {
+	var flags []string
+	if m.def.Type != "" && m.def.Type != "table" {
+		flags = append(flags, m.def.Type)
+	}
+	if m.def.WithoutRowid {
+		flags = append(flags, "WITHOUT ROWID")
+	}
+	if m.def.Strict {
+		flags = append(flags, "STRICT")
+	}
+	return strings.Join(flags, " • ")
+}
+
+// highlighted CREATE statements of the table, its indexes and triggers
+func (m *model) ddlLines() []string {
+	stmts := []string{m.def.SQL}
+	for _, idx := range m.indexes {
+		if idx.SQL != "" {
+			stmts = append(stmts, idx.SQL)
+		}
+	}
+	for _, t := range m.triggers {
+		stmts = append(stmts, t.SQL)
+	}
+	return strings.Split(highlightSQL(strings.Join(stmts, ";\n\n")+";"), "\n")
+}
+
+// DDL lines on screen, the rest of the pane holds the tabs, title and hint
+func (m *model) ddlHeight() int {
+	return max(m.height-12, 5)
 }
@@ -0,0 +1,425 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type jsonKind int
+
+const (
+	jsonObject jsonKind = iota
+	jsonArray
+	jsonString
+	jsonNumber
+	jsonBool
+	jsonNull
+)
+
+// one value of the document, objects keep their key order
+type jsonNode struct {
+	key       string // object key or array index, empty for the root
+	path      string // SQLite JSON path, ex. $.tags[0]
+	kind      jsonKind
+	value     any // string, json.Number or bool for scalars
+	children  []*jsonNode
+	depth     int
+	collapsed bool
+}
+
+func (n *jsonNode) isContainer() bool {
+	return n.kind == jsonObject || n.kind == jsonArray
+}
+
+// tree view of a JSON cell of the selected row
+type jsonView struct {
+	col       int   // column being viewed
+	cols      []int // columns with JSON values
+	row       []any
+	doc       string
+	root      *jsonNode
+	lines     []*jsonNode // visible nodes in display order
+	cursor    int
+	offset    int // first visible line
+	height    int // number of visible lines
+	input     textinput.Model
+	prompting bool
+	result    string // value of the last evaluated path
+}
+
+var (
+	jsonKeyStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	jsonStringStyle = lipgloss.NewStyle().Foreground(green)
+	jsonNumberStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("215"))
+	jsonLitStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	jsonPunctStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	jsonCursorStyle = lipgloss.NewStyle().Background(lipgloss.Color("57")).Foreground(lipgloss.Color("229"))
+)
+
+// object or array text, plain scalars are not worth a tree
+func jsonText(val any) (string, bool) {
+	var s string
+	switch v := val.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return "", false
+	}
+
+	t := strings.TrimSpace(s)
+	if t == "" || (t[0] != '{' && t[0] != '[') || !json.Valid([]byte(t)) {
+		return "", false
+	}
+	return t, true
+}
+
+func (m *model) openJSON(row []any) error {
+	var cols []int
+	for i := range m.columns {
+		if _, ok := jsonText(row[i]); ok {
+			cols = append(cols, i)
+		}
+	}
+	if len(cols) == 0 {
+		return errors.New("No JSON values in this row")
+	}
+
+	input := textinput.New()
+	input.Prompt = "Path: "
+	input.Width = 40
+
+	m.openTab(jsonTab, "JSON")
+	m.json = jsonView{cols: cols, row: row, input: input, height: max(m.dataTable.Height()-4, 3)}
+	return m.setJSONColumn(cols[0])
+}
+
+func (m *model) setJSONColumn(i int) error {
+	doc, _ := jsonText(m.json.row[i])
+	root, err := parseJSON(doc)
+	if err != nil {
+		return fmt.Errorf("Failed to parse JSON: %w", err)
+	}
+
+	j := &m.json
+	j.col = i
+	j.doc = doc
+	j.root = root
+	j.cursor = 0
+	j.offset = 0
+	j.result = ""
+	j.refresh()
+	return nil
+}
+
+func (m *model) updateJSON(msg tea.KeyMsg) tea.Cmd {
+	j := &m.json
+
+	if j.prompting {
+		switch {
+		case key.Matches(msg, keys.Back):
+			j.prompting = false
+			j.input.Blur()
+			return nil
+		case key.Matches(msg, keys.Enter):
+			j.prompting = false
+			j.input.Blur()
+			path := strings.TrimSpace(j.input.Value())
+			if path == "" {
+				return nil
+			}
+			j.reveal(path)
+			return jsonExtractCmd(m.store, j.doc, path)
+		}
+
+		var cmd tea.Cmd
+		j.input, cmd = j.input.Update(msg)
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.closeTab(dataTab)
+	case key.Matches(msg, keys.Left):
+		m.moveTab(-1)
+	case key.Matches(msg, keys.Right):
+		m.moveTab(1)
+	case key.Matches(msg, keys.Tab):
+		m.activeTab = m.nextTab()
+	case key.Matches(msg, keys.Up):
+		j.move(-1)
+	case key.Matches(msg, keys.Down):
+		j.move(1)
+	case key.Matches(msg, keys.Enter), msg.String() == " ":
+		if n := j.current(); n != nil && n.isContainer() {
+			n.collapsed = !n.collapsed
+			j.refresh()
+		}
+	case key.Matches(msg, keys.Column):
+		for n, i := range j.cols {
+			if i == j.col {
+				if err := m.setJSONColumn(j.cols[(n+1)%len(j.cols)]); err != nil {
+					return errCmd(err)
+				}
+				break
+			}
+		}
+	case key.Matches(msg, keys.JSONPath):
+		j.prompting = true
+		if n := j.current(); n != nil {
+			j.input.SetValue(n.path)
+		}
+		j.input.CursorEnd()
+		return j.input.Focus()
+	case key.Matches(msg, keys.JSONFilter):
+		n := j.current()
+		if n == nil {
+			return nil
+		}
+		filter := database.JSONFilter(m.columns[j.col].Name, n.path, n.sqlValue(), n.isContainer())
+		return m.editFilter(filter)
+	}
+	return nil
+}
+
+// value as json_extract returns it, objects and arrays give nil
+func (n *jsonNode) sqlValue() any {
+	switch v := n.value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+func (j *jsonView) current() *jsonNode {
+	if j.cursor < 0 || j.cursor >= len(j.lines) {
+		return nil
+	}
+	return j.lines[j.cursor]
+}
+
+// moves the cursor, scrolling to keep it visible
+func (j *jsonView) move(d int) {
+	j.cursor = min(max(j.cursor+d, 0), len(j.lines)-1)
+	if j.cursor < j.offset {
+		j.offset = j.cursor
+	} else if j.cursor >= j.offset+j.height {
+		j.offset = j.cursor - j.height + 1
+	}
+}
+
+// rebuilds the visible lines after expanding or collapsing
+func (j *jsonView) refresh() {
+	j.lines = j.lines[:0]
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		j.lines = append(j.lines, n)
+		if n.collapsed {
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(j.root)
+	j.move(0)
+}
+
+// expands the nodes down to path and moves the cursor to it
+func (j *jsonView) reveal(path string) {
+	var find func(n *jsonNode) bool
+	find = func(n *jsonNode) bool {
+		if n.path == path {
+			return true
+		}
+		for _, c := range n.children {
+			if find(c) {
+				n.collapsed = false
+				return true
+			}
+		}
+		return false
+	}
+	if !find(j.root) {
+		return
+	}
+
+	j.refresh()
+	for i, n := range j.lines {
+		if n.path == path {
+			j.cursor = i
+		}
+	}
+	j.move(0)
+}
+
+func (m model) jsonView() string {
+	j := m.json
+	col := m.columns[j.col]
+
+	var lines []string
+	for i := j.offset; i < min(j.offset+j.height, len(j.lines)); i++ {
+		line := renderJSONLine(j.lines[i])
+		if i == j.cursor {
+			line = jsonCursorStyle.Render(">") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, line)
+	}
+
+	info := fmt.Sprintf("%s (%s) • %d/%d", col.Name, col.Type, j.cursor+1, len(j.lines))
+	path := ""
+	if n := j.current(); n != nil {
+		path = n.path
+	}
+
+	footer := jsonPunctStyle.Render(fmt.Sprintf("%s • enter fold • %s %s • %s %s • %s %s",
+		path,
+		keys.JSONPath.Help().Key, keys.JSONPath.Help().Desc,
+		keys.JSONFilter.Help().Key, keys.JSONFilter.Help().Desc,
+		keys.Column.Help().Key, keys.Column.Help().Desc))
+	if j.prompting {
+		footer = j.input.View()
+	} else if j.result != "" {
+		footer = j.result + "\n" + footer
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.appBoundaryView(info),
+		strings.Join(lines, "\n"),
+		"",
+		footer,
+	)
+}
+
+func renderJSONLine(n *jsonNode) string {
+	var b strings.Builder
+	b.WriteString(strings.Repeat("  ", n.depth))
+
+	if n.isContainer() {
+		if n.collapsed {
+			b.WriteString(jsonPunctStyle.Render("▸ "))
+		} else {
+			b.WriteString(jsonPunctStyle.Render("▾ "))
+		}
+	} else {
+		b.WriteString("  ")
+	}
+
+	if n.key != "" {
+		b.WriteString(jsonKeyStyle.Render(n.key))
+		b.WriteString(jsonPunctStyle.Render(": "))
+	}
+
+	switch n.kind {
+	case jsonObject:
+		b.WriteString(jsonPunctStyle.Render(fmt.Sprintf("{%d}", len(n.children))))
+	case jsonArray:
+		b.WriteString(jsonPunctStyle.Render(fmt.Sprintf("[%d]", len(n.children))))
+	case jsonString:
+		b.WriteString(jsonStringStyle.Render(strconv.Quote(n.value.(string))))
+	case jsonNumber:
+		b.WriteString(jsonNumberStyle.Render(n.value.(json.Number).String()))
+	case jsonBool:
+		b.WriteString(jsonLitStyle.Render(strconv.FormatBool(n.value.(bool))))
+	case jsonNull:
+		b.WriteString(jsonLitStyle.Render("null"))
+	}
+	return b.String()
+}
+
+// shows the result of a path typed by the user
+func (m *model) setJSONResult(msg jsonExtractedMsg) {
+	if msg.err != nil {
+		m.json.result = errorStyle.Render(msg.err.Error())
+		return
+	}
+	m.json.result = fmt.Sprintf("%s = %s", msg.path, database.FormatValue(msg.value))
+}
+
+// decodes a document token by token so object keys stay in order
+func parseJSON(doc string) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(doc)))
+	dec.UseNumber()
+	return parseJSONValue(dec, "", "$", 0)
+}
+
+func parseJSONValue(dec *json.Decoder, key, path string, depth int) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &jsonNode{key: key, path: path, depth: depth, value: tok}
+	switch t := tok.(type) {
+	case json.Delim:
+		n.value = nil
+		if t == '{' {
+			n.kind = jsonObject
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				name, _ := k.(string)
+				child, err := parseJSONValue(dec, name, path+jsonPathKey(name), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				n.children = append(n.children, child)
+			}
+		} else {
+			n.kind = jsonArray
+			for i := 0; dec.More(); i++ {
+				child, err := parseJSONValue(dec, strconv.Itoa(i), fmt.Sprintf("%s[%d]", path, i), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				n.children = append(n.children, child)
+			}
+		}
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		n.kind = jsonString
+	case json.Number:
+		n.kind = jsonNumber
+	case bool:
+		n.kind = jsonBool
+	case nil:
+		n.kind = jsonNull
+	}
+	return n, nil
+}
+
+var jsonIdentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// escapes backslashes and quotes inside a quoted key
+var jsonKeyEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// path step for an object key, quoted unless it is a plain identifier
+func jsonPathKey(name string) string {
+	if jsonIdentPattern.MatchString(name) {
+		return "." + name
+	}
+	return `."` + jsonKeyEscaper.Replace(name) + `"`
+}
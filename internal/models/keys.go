@@ -3,18 +3,80 @@ package models
 import "github.com/charmbracelet/bubbles/key"
 
 type keyMap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Left   key.Binding
-	Right  key.Binding
-	Quit   key.Binding
-	Back   key.Binding
-	Enter  key.Binding
-	Tab    key.Binding
-	Help   key.Binding
-	Filter key.Binding
-	Edit   key.Binding
-	Reset  key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	Quit     key.Binding
+	Back     key.Binding
+	Enter    key.Binding
+	Tab      key.Binding
+	Help     key.Binding
+	Filter   key.Binding
+	Edit     key.Binding
+	Insert   key.Binding
+	Delete   key.Binding
+	Reset    key.Binding
+	Undo     key.Binding
+	Redo     key.Binding
+	Null     key.Binding
+	Pending  key.Binding
+	Commit   key.Binding
+	Rollback key.Binding
+	// blob inspector and JSON viewer
+	Blob       key.Binding
+	BlobSave   key.Binding
+	BlobLoad   key.Binding
+	BlobText   key.Binding
+	JSON       key.Binding
+	JSONPath   key.Binding
+	JSONFilter key.Binding
+	Column     key.Binding
+	// Data tab columns
+	ColLeft  key.Binding
+	ColRight key.Binding
+	Widen    key.Binding
+	Narrow   key.Binding
+	Freeze   key.Binding
+	Columns  key.Binding
+	// record view
+	NextRow key.Binding
+	PrevRow key.Binding
+	Copy    key.Binding
+	// foreign keys
+	FollowRef key.Binding
+	RefBy     key.Binding
+	NavBack   key.Binding
+	// table diff
+	Diff    key.Binding
+	DiffSQL key.Binding
+	// generated rows
+	Generate key.Binding
+	// schema diff
+	SchemaDiff key.Binding
+	Swap       key.Binding
+	// migrations
+	Migrations  key.Binding
+	MigrateUp   key.Binding
+	MigrateDown key.Binding
+	// schema diagram
+	Diagram       key.Binding
+	ZoomIn        key.Binding
+	ZoomOut       key.Binding
+	ExportDOT     key.Binding
+	ExportMermaid key.Binding
+	// database overview
+	Overview key.Binding
+	Refresh  key.Binding
+	// list view
+	Attach key.Binding
+	Detach key.Binding
+	// Info tab
+	NewIndex    key.Binding
+	DropIndex   key.Binding
+	EditTrigger key.Binding
+	DropTrigger key.Binding
+	DDL         key.Binding
 }
 
 var keys = keyMap{
@@ -62,10 +124,232 @@ var keys = keyMap{
 		key.WithKeys("e"),
 		key.WithHelp("e", "edit"),
 	),
+	Insert: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "insert row"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete row"),
+	),
 	Reset: key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "clear query/filter"),
+	),
+	Undo: key.NewBinding(
 		key.WithKeys("ctrl+z"),
 		key.WithHelp("ctrl+z", "undo"),
 	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "redo"),
+	),
+	Null: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "toggle NULL (form)"),
+	),
+	// transaction mode only
+	Pending: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "pending changes"),
+		key.WithDisabled(),
+	),
+	Commit: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "commit"),
+		key.WithDisabled(),
+	),
+	Rollback: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "rollback"),
+		key.WithDisabled(),
+	),
+	Blob: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "inspect blob"),
+	),
+	BlobSave: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "save to file"),
+	),
+	BlobLoad: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "load from file"),
+	),
+	BlobText: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "hex/text"),
+	),
+	JSON: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "view JSON"),
+	),
+	JSONPath: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "query path"),
+	),
+	JSONFilter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "filter rows on value"),
+	),
+	Column: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "next column"),
+	),
+	ColLeft: key.NewBinding(
+		key.WithKeys("H", "shift+left"),
+		key.WithHelp("H/L", "focus column"),
+	),
+	ColRight: key.NewBinding(
+		key.WithKeys("L", "shift+right"),
+		key.WithHelp("L", "focus column right"),
+	),
+	Widen: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+/-", "resize column"),
+	),
+	Narrow: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "narrow column"),
+	),
+	Freeze: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "freeze columns"),
+	),
+	Columns: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "show/hide columns"),
+	),
+	NextRow: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next row"),
+	),
+	PrevRow: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous row"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy field"),
+	),
+	FollowRef: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "follow foreign key"),
+	),
+	RefBy: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "referenced by"),
+	),
+	NavBack: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "go back"),
+	),
+	Diff: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "diff with table"),
+	),
+	DiffSQL: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "show sync SQL"),
+	),
+	Generate: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "generate rows"),
+	),
+	SchemaDiff: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "schema diff"),
+	),
+	Swap: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "swap sides"),
+	),
+	Migrations: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "migrations"),
+	),
+	MigrateUp: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "apply next migration"),
+	),
+	MigrateDown: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "roll back migration"),
+	),
+	Diagram: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "schema diagram"),
+	),
+	ZoomIn: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "zoom in"),
+	),
+	ZoomOut: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "zoom out"),
+	),
+	ExportDOT: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "copy as DOT"),
+	),
+	ExportMermaid: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "copy as Mermaid"),
+	),
+	Overview: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "database overview"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "refresh overview"),
+	),
+	Attach: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "attach database"),
+	),
+	Detach: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "detach database (list)"),
+	),
+	NewIndex: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new index"),
+	),
+	DropIndex: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "drop index"),
+	),
+	EditTrigger: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "new/edit trigger"),
+	),
+	DropTrigger: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "drop trigger"),
+	),
+	DDL: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "show DDL"),
+	),
+}
+
+// greys out bindings that would write to the database
+func (k *keyMap) setReadOnly() {
+	for _, b := range []*key.Binding{&k.Edit, &k.Insert, &k.Delete, &k.Undo, &k.Redo, &k.BlobLoad, &k.NewIndex, &k.DropIndex, &k.EditTrigger, &k.DropTrigger, &k.MigrateUp, &k.MigrateDown, &k.Generate} {
+		h := b.Help()
+		b.SetHelp(
+			disabledKeyStyle.Render(h.Key),
+			disabledKeyStyle.Render(h.Desc+" (read-only)"),
+		)
+	}
+}
+
+// shows the transaction keys
+func (k *keyMap) setTxMode() {
+	k.Pending.SetEnabled(true)
+	k.Commit.SetEnabled(true)
+	k.Rollback.SetEnabled(true)
 }
 
 // returns mini help view
@@ -81,5 +365,20 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		{k.Tab, k.Help},
 		{k.Filter, k.Quit},
 		{k.Edit, k.Reset},
+		{k.Insert, k.Delete},
+		{k.Blob, k.JSON},
+		{k.ColLeft, k.Widen},
+		{k.Freeze, k.Columns},
+		{k.FollowRef, k.RefBy, k.NavBack},
+		{k.Diff, k.DiffSQL},
+		{k.Generate},
+		{k.Undo, k.Redo, k.Null},
+		{k.Pending, k.Commit, k.Rollback},
+		{k.Diagram, k.Overview, k.Refresh},
+		{k.Attach, k.Detach},
+		{k.SchemaDiff, k.Swap},
+		{k.Migrations, k.MigrateUp, k.MigrateDown},
+		{k.NewIndex, k.DropIndex},
+		{k.EditTrigger, k.DropTrigger, k.DDL},
 	}
 }
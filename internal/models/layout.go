@@ -0,0 +1,298 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	minColWidth    = 3
+	maxColWidth    = 40
+	layoutSample   = 50 // rows looked at when sizing columns
+	focusedColMark = "▸"
+	refMark        = "→"
+)
+
+// how the Data tab shows the columns of a table, kept per table while the app runs
+type columnLayout struct {
+	widths  []int
+	hidden  []bool
+	frozen  int // leading columns that stay on screen when scrolling
+	focused int // column that is resized, frozen up to, etc.
+	offset  int // first unfrozen column on screen
+}
+
+// sizes each column to fit its title and a sample of its values
+func autoWidths(titles []string, rows []table.Row, maxWidth int) []int {
+	widths := make([]int, len(titles))
+	for i, title := range titles {
+		widths[i] = lipgloss.Width(title) + lipgloss.Width(focusedColMark)
+	}
+
+	for _, row := range rows[:min(len(rows), layoutSample)] {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	for i := range widths {
+		widths[i] = min(max(widths[i], minColWidth), maxColWidth, max(maxWidth, minColWidth))
+	}
+	return widths
+}
+
+// room for columns inside the table border, each cell has 1 space of padding per side
+func (m *model) tableWidth() int {
+	return max(m.width-6, 20)
+}
+
+// returns the layout of the current table, creating it on first use
+func (m *model) layout() *columnLayout {
+	l, ok := m.layouts[m.name]
+	if ok && len(l.widths) == len(m.columns) {
+		return l
+	}
+
+	frozen := 0
+	for i, col := range m.columns {
+		// leading key columns stay visible by default
+		if col.PK && frozen == i {
+			frozen++
+		}
+	}
+	if frozen == len(m.columns) {
+		frozen = 0
+	}
+
+	l = newLayout(m.columnTitles(), m.rows, m.tableWidth(), frozen)
+	m.layouts[m.name] = l
+	return l
+}
+
+func newLayout(titles []string, rows [][]any, width, frozen int) *columnLayout {
+	return &columnLayout{
+		widths: autoWidths(titles, toTableRows(rows), width-2),
+		hidden: make([]bool, len(titles)),
+		frozen: frozen,
+	}
+}
+
+// column names for the Data tab header, foreign keys are marked with an arrow
+func (m *model) columnTitles() []string {
+	names := make([]string, len(m.columns))
+	for i, col := range m.columns {
+		names[i] = col.Name
+		if col.RefTable != "" {
+			names[i] += refMark
+		}
+	}
+	return names
+}
+
+// columns on screen: frozen ones, then as many as fit starting at the scroll offset
+func (l *columnLayout) visible(width int) []int {
+	var cols []int
+	used := 0
+	for i := 0; i < l.frozen && i < len(l.widths); i++ {
+		if !l.hidden[i] {
+			cols = append(cols, i)
+			used += l.widths[i] + 2
+		}
+	}
+
+	scrolled := 0
+	for i := max(l.offset, l.frozen); i < len(l.widths); i++ {
+		if l.hidden[i] {
+			continue
+		}
+		// always show at least one scrolling column
+		if used+l.widths[i]+2 > width && scrolled > 0 {
+			break
+		}
+		cols = append(cols, i)
+		used += l.widths[i] + 2
+		scrolled++
+	}
+	return cols
+}
+
+// scrolls so the focused column is on screen
+func (l *columnLayout) scrollToFocus(width int) {
+	if l.focused < l.frozen {
+		return
+	}
+	if l.focused < l.offset {
+		l.offset = l.focused
+	}
+	for l.offset < l.focused {
+		cols := l.visible(width)
+		if len(cols) > 0 && cols[len(cols)-1] >= l.focused {
+			break
+		}
+		l.offset++
+	}
+}
+
+// moves the focus to the next visible column in direction d
+func (l *columnLayout) moveFocus(d int) {
+	for i := l.focused + d; i >= 0 && i < len(l.widths); i += d {
+		if !l.hidden[i] {
+			l.focused = i
+			return
+		}
+	}
+}
+
+// applies the layout of the current table to the Data tab
+func (m *model) applyLayout() {
+	if m.name == "" {
+		return
+	}
+	m.visibleCols = m.layout().apply(&m.dataTable, m.columnTitles(), m.rows, m.tableWidth())
+}
+
+// fills the table with the columns that fit in width, returns their indexes
+func (l *columnLayout) apply(t *table.Model, titles []string, rows [][]any, width int) []int {
+	l.scrollToFocus(width)
+	visible := l.visible(width)
+
+	cols := make([]table.Column, len(visible))
+	for n, i := range visible {
+		title := titles[i]
+		if i == l.focused {
+			title = focusedColMark + title
+		}
+		cols[n] = table.Column{Title: title, Width: l.widths[i]}
+	}
+
+	all := toTableRows(rows)
+	tableRows := make([]table.Row, len(all))
+	for r, row := range all {
+		cells := make(table.Row, len(visible))
+		for n, i := range visible {
+			cells[n] = row[i]
+		}
+		tableRows[r] = cells
+	}
+
+	// rows are cleared first so they never have fewer cells than the columns,
+	// which also clears the cursor
+	cursor := t.Cursor()
+	t.SetRows(nil)
+	t.SetColumns(cols)
+	t.SetRows(tableRows)
+	t.SetCursor(cursor)
+	return visible
+}
+
+// number of shown columns scrolled off to the left and right
+func (l *columnLayout) offscreen(visible []int) (int, int) {
+	if len(visible) == 0 {
+		return 0, 0
+	}
+
+	left, right := 0, 0
+	for i := l.frozen; i < len(l.widths); i++ {
+		if l.hidden[i] {
+			continue
+		}
+		if i < visible[len(visible)-1] && !slices.Contains(visible, i) {
+			left++
+		} else if i > visible[len(visible)-1] {
+			right++
+		}
+	}
+	return left, right
+}
+
+// changes the width of the focused column by d
+func (m *model) resizeColumn(d int) {
+	l := m.layout()
+	l.widths[l.focused] = min(max(l.widths[l.focused]+d, minColWidth), m.tableWidth()-2)
+	m.applyLayout()
+}
+
+// freezes the columns up to and including the focused one, or unfreezes them
+func (m *model) toggleFreeze() {
+	l := m.layout()
+	if l.frozen == l.focused+1 {
+		l.frozen = 0
+	} else {
+		l.frozen = l.focused + 1
+	}
+	l.offset = l.frozen
+	m.applyLayout()
+}
+
+// opens a multi select of the columns to show
+func (m *model) openColumnPicker() {
+	l := m.layout()
+
+	options := make([]huh.Option[int], len(m.columns))
+	shown := new([]int)
+	for i, col := range m.columns {
+		options[i] = huh.NewOption(col.Name+" ("+col.Type+")", i).Selected(!l.hidden[i])
+		if !l.hidden[i] {
+			*shown = append(*shown, i)
+		}
+	}
+
+	m.openTab(columnsTab, "Columns")
+	m.shownCols = shown
+	m.picker = huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Visible columns").
+				Description("x/space toggles, enter saves").
+				Options(options...).
+				Height(min(len(options)+2, max(m.dataTable.Height(), 5))).
+				Value(shown),
+		),
+	).WithWidth(45).WithShowHelp(false)
+}
+
+// hides the columns left out of the picker, showing all if none were picked
+func (m *model) applyColumnPicker() {
+	l := m.layout()
+	for i := range l.hidden {
+		l.hidden[i] = len(*m.shownCols) > 0
+	}
+	for _, i := range *m.shownCols {
+		l.hidden[i] = false
+	}
+
+	if l.hidden[l.focused] {
+		l.moveFocus(1)
+	}
+	if l.hidden[l.focused] {
+		l.moveFocus(-1)
+	}
+	m.applyLayout()
+}
+
+func (m model) columnsInfo() string {
+	l := m.layouts[m.name]
+	if l == nil {
+		return ""
+	}
+
+	hidden := 0
+	for _, h := range l.hidden {
+		if h {
+			hidden++
+		}
+	}
+
+	s := fmt.Sprintf("column %d/%d", l.focused+1, len(l.widths))
+	if l.frozen > 0 {
+		s += fmt.Sprintf(" • %d frozen", l.frozen)
+	}
+	if hidden > 0 {
+		s += fmt.Sprintf(" • %d hidden", hidden)
+	}
+	return s
+}
@@ -1,6 +1,11 @@
 package models
 
 import (
+	"fmt"
+	"path/filepath"
+
+	"dbtui/internal/database"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -8,26 +13,56 @@ import (
 )
 
 type tableList struct {
-	focus  bool
-	list   list.Model // list of table names
-	tables []string
-	width  int
-	height int
+	focus     bool
+	list      list.Model // list of table names, under their schema when several are open
+	schemas   []database.Schema
+	collapsed map[string]bool // schemas whose tables are hidden
+	tables    []string
+	width     int
+	height    int
 }
 
 // implements list.Item interface
-type tableItem string
+type tableItem struct {
+	name  string // as taken by the Manager, ex. aux.users
+	title string
+}
 
-func (t tableItem) FilterValue() string { return string(t) }
-func (t tableItem) Title() string       { return string(t) }
+func (t tableItem) FilterValue() string { return t.name }
+func (t tableItem) Title() string       { return t.title }
 func (t tableItem) Description() string { return "" }
 
+// a database heading the tables in it
+type schemaItem struct {
+	name      string
+	file      string
+	tables    int
+	collapsed bool
+}
+
+func (s schemaItem) FilterValue() string { return s.name }
+func (s schemaItem) Description() string { return filepath.Base(s.file) }
+
+func (s schemaItem) Title() string {
+	arrow := "▾"
+	if s.collapsed {
+		arrow = "▸"
+	}
+	return fmt.Sprintf("%s %s (%d)", arrow, s.name, s.tables)
+}
+
+// true for databases added with ATTACH
+func (s schemaItem) attached() bool {
+	return s.name != "main" && s.name != "temp"
+}
+
 func newTableList() tableList {
 	list := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	list.SetShowHelp(false)
 	return tableList{
-		focus: true,
-		list: list,
+		focus:     true,
+		list:      list,
+		collapsed: map[string]bool{},
 	}
 }
 
@@ -35,15 +70,48 @@ func (tl *tableList) setFocus(f bool) {
 	tl.focus = f
 }
 
-func (tl *tableList) setTables(tables []string) {
-	tl.tables = tables
+func (tl *tableList) setSchemas(schemas []database.Schema) {
+	tl.schemas = nil
+	tl.tables = nil
+	for _, s := range schemas {
+		// temp only shows once it has tables
+		if s.Name == "temp" && len(s.Tables) == 0 {
+			continue
+		}
+		tl.schemas = append(tl.schemas, s)
+		tl.tables = append(tl.tables, s.Tables...)
+	}
+	tl.setItems()
+	tl.list.Title = "Database Tables"
+}
 
-	items := make([]list.Item, len(tables))
-	for i, t := range tables {
-		items[i] = tableItem(t)
+// lists the tables, grouped under their schema when more than main is open
+func (tl *tableList) setItems() {
+	var items []list.Item
+	tree := len(tl.schemas) > 1
+	for _, s := range tl.schemas {
+		if tree {
+			items = append(items, schemaItem{s.Name, s.File, len(s.Tables), tl.collapsed[s.Name]})
+			if tl.collapsed[s.Name] {
+				continue
+			}
+		}
+		for _, t := range s.Tables {
+			title := t
+			if tree {
+				// the schema is shown by the heading
+				title = "  " + unqualified(s.Name, t)
+			}
+			items = append(items, tableItem{t, title})
+		}
 	}
 	tl.list.SetItems(items)
-	tl.list.Title = "Database Tables"
+}
+
+// the selected schema heading, if one is selected
+func (tl tableList) selectedSchema() (schemaItem, bool) {
+	s, ok := tl.list.SelectedItem().(schemaItem)
+	return s, ok
 }
 
 func (tl *tableList) setSize(width, height int) {
@@ -63,8 +131,15 @@ func (tl tableList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, keys.Enter):
-			if item, ok := tl.list.SelectedItem().(tableItem); ok {
-				return tl, selectTableCmd(string(item))
+			switch item := tl.list.SelectedItem().(type) {
+			case tableItem:
+				return tl, selectTableCmd(item.name)
+			case schemaItem:
+				tl.collapsed[item.name] = !tl.collapsed[item.name]
+				index := tl.list.Index()
+				tl.setItems()
+				tl.list.Select(index)
+				return tl, nil
 			}
 		}
 	}
@@ -75,7 +150,7 @@ func (tl tableList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (tl tableList) View() string {
 	style := lipgloss.NewStyle()
-	
+
 	if tl.focus {
 		style = style.
 			Border(lipgloss.RoundedBorder()).
@@ -87,10 +162,18 @@ func (tl tableList) View() string {
 			BorderForeground(lipgloss.Color("240")). // Gray when not focused
 			Padding(0, 1)
 	}
-	
+
 	return style.Render(tl.list.View())
 }
 
 // func (tl tableList) View() string {
 // 	return tl.list.View()
 // }
+
+// strips the schema ListTables puts before tables outside main
+func unqualified(schema, table string) string {
+	if schema == "main" {
+		return table
+	}
+	return table[len(schema)+1:]
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"fmt"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// values bound to the form confirming a migration step
+type migrationStep struct {
+	migration database.Migration
+	up        bool
+	confirm   bool
+}
+
+// applied and pending migrations of the migrations directory, replaces the table view
+type migrationsPanel struct {
+	dir        string
+	migrations []database.Migration
+	err        error // reading the directory failed
+	table      table.Model
+	form       *huh.Form      // confirms the next step
+	step       *migrationStep // bound to form
+	width      int
+	height     int
+}
+
+func newMigrationsPanel() migrationsPanel {
+	return migrationsPanel{table: newTable()}
+}
+
+func (p *migrationsPanel) setSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.table.SetWidth(width - 6)
+	p.table.SetHeight(max(height-8, 3))
+}
+
+func (p *migrationsPanel) setMigrations(msg migrationsLoadedMsg) {
+	p.dir = msg.dir
+	p.migrations = msg.migrations
+	p.err = msg.err
+
+	p.table.SetColumns([]table.Column{
+		{Title: "Version", Width: 8},
+		{Title: "Name", Width: max(p.width-58, 16)},
+		{Title: "Status", Width: 28},
+		{Title: "Down", Width: 6},
+	})
+
+	rows := make([]table.Row, len(p.migrations))
+	for i, mig := range p.migrations {
+		status := "pending"
+		switch {
+		case mig.Applied && mig.Up == "":
+			status = "applied, file missing"
+		case mig.Applied:
+			status = "applied " + mig.AppliedAt
+		}
+		down := "no"
+		if mig.Down != "" {
+			down = "yes"
+		}
+		rows[i] = table.Row{fmt.Sprintf("%04d", mig.Version), mig.Name, status, down}
+	}
+	p.table.SetRows(rows)
+
+	// the cursor starts on the next migration to apply
+	cursor := len(rows) - 1
+	for i, mig := range p.migrations {
+		if !mig.Applied {
+			cursor = i
+			break
+		}
+	}
+	p.table.SetCursor(max(cursor, 0))
+}
+
+// opens the form showing the SQL of the next step, up applies the next pending
+// migration and down rolls back the last applied one
+func (p *migrationsPanel) confirm(up bool) tea.Cmd {
+	mig, ok := database.NextMigration(p.migrations)
+	action := "Apply"
+	if !up {
+		mig, ok = database.LastMigration(p.migrations)
+		action = "Roll back"
+	}
+	switch {
+	case p.err != nil:
+		return nil
+	case !ok && up:
+		return statusCmd("Every migration is applied")
+	case !ok:
+		return statusCmd("No applied migration to roll back")
+	case !up && mig.Down == "":
+		return statusCmd("%s has no .down.sql file", mig)
+	}
+
+	sql, err := database.MigrationSQL(mig, up)
+	if err != nil {
+		return errCmd(err)
+	}
+
+	step := &migrationStep{migration: mig, up: up}
+	p.step = step
+	p.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title(mig.String()).
+				Description(noteText(sql)),
+			huh.NewConfirm().
+				Title(fmt.Sprintf("%s %s?", action, mig)).
+				Description("runs in a transaction with the bookkeeping update").
+				Value(&step.confirm),
+		),
+	).WithWidth(max(p.width-6, 30)).WithShowHelp(false)
+	return p.form.Init()
+}
+
+func (p *migrationsPanel) closeForm() {
+	p.form = nil
+	p.step = nil
+}
+
+// passes msg to the open form, running the step once it is confirmed, or
+// moves the cursor
+func (p *migrationsPanel) update(msg tea.Msg, store *database.Manager) tea.Cmd {
+	if p.form == nil {
+		keyMsg, ok := msg.(tea.KeyMsg)
+		switch {
+		case !ok:
+			return nil
+		case key.Matches(keyMsg, keys.MigrateUp):
+			return p.confirm(true)
+		case key.Matches(keyMsg, keys.MigrateDown):
+			return p.confirm(false)
+		}
+		var cmd tea.Cmd
+		p.table, cmd = p.table.Update(msg)
+		return cmd
+	}
+
+	form, cmd := p.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		p.form = f
+	}
+
+	switch p.form.State {
+	case huh.StateCompleted:
+		step := p.step
+		p.closeForm()
+		if !step.confirm {
+			return nil
+		}
+		return migrateCmd(store, p.dir, step.up)
+	case huh.StateAborted:
+		p.closeForm()
+		return nil
+	}
+	return cmd
+}
+
+func (p migrationsPanel) View() string {
+	applied := 0
+	for _, mig := range p.migrations {
+		if mig.Applied {
+			applied++
+		}
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170")).
+		Render(fmt.Sprintf("Migrations (%d applied, %d pending)", applied, len(p.migrations)-applied))
+	dir := dashboardDimStyle.Render(p.dir)
+
+	var body, hint string
+	switch {
+	case p.form != nil:
+		body = p.form.View()
+		hint = "enter next • esc cancel"
+	case p.err != nil:
+		body = fmt.Sprintf("%v\n\nSet the directory of NNNN_name.up.sql/.down.sql files with -migrations", p.err)
+		hint = "esc close"
+	case len(p.migrations) == 0:
+		body = "No NNNN_name.up.sql files in the directory"
+		hint = "esc close"
+	default:
+		body = baseStyle.Render(p.table.View())
+		hint = fmt.Sprintf("%s apply next • %s roll back last • esc close",
+			keys.MigrateUp.Help().Key, keys.MigrateDown.Help().Key)
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlightColor).
+		Width(p.width - 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, dir, "", body, dashboardDimStyle.Render(hint)))
+}
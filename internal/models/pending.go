@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lists uncommitted changes in transaction mode
+type pendingPanel struct {
+	table  table.Model
+	count  int
+	width  int
+	height int
+}
+
+func newPendingPanel() pendingPanel {
+	return pendingPanel{table: newTable()}
+}
+
+func (p *pendingPanel) setSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.table.SetWidth(width - 2)
+	p.table.SetHeight(max(height-6, 3))
+}
+
+func (p *pendingPanel) setChanges(changes []database.Change) {
+	p.count = len(changes)
+
+	p.table.SetColumns([]table.Column{
+		{Title: "#", Width: 4},
+		{Title: "Op", Width: 7},
+		{Title: "Table", Width: 12},
+		{Title: "Key", Width: 10},
+		{Title: "Column", Width: 12},
+		{Title: "Old", Width: 15},
+		{Title: "New", Width: 15},
+	})
+
+	var rows []table.Row
+	for i, c := range changes {
+		for _, d := range c.Diff() {
+			rows = append(rows, table.Row{
+				fmt.Sprintf("%d", i+1),
+				c.Kind.String(),
+				c.Table,
+				c.Key,
+				d.Column,
+				d.Old,
+				d.New,
+			})
+		}
+	}
+
+	p.table.SetRows(rows)
+	p.table.GotoTop()
+}
+
+func (p pendingPanel) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170")).
+		Render(fmt.Sprintf("Pending Changes (%d)", p.count))
+
+	body := baseStyle.Render(p.table.View())
+	if p.count == 0 {
+		body = "No uncommitted changes"
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlightColor).
+		Width(p.width - 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body))
+}
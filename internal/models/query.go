@@ -2,8 +2,6 @@ package models
 
 import (
 	"fmt"
-
-	"github.com/charmbracelet/bubbles/table"
 )
 
 func (m *model) queryView() string {
@@ -13,38 +11,30 @@ func (m *model) queryView() string {
 	if m.err != nil {
 		view += fmt.Sprintf("Error: %s\n", m.err)
 	} else if len(m.queryResult) > 0 {
-		view += m.queryTable.View()
+		view += m.gridView(m.queryTable, m.queryResult, m.queryCols, m.queryLayout, m.queryTop)
 	}
 
 	return view
 }
 
-func (m *model) setQueryResult(columns []string, rows [][]string, err error) {
+func (m *model) setQueryResult(columns []string, rows [][]any, err error) {
 	m.err = err
 	m.queryResult = rows
 
 	if err == nil && len(rows) > 0 {
-		tableCols := make([]table.Column, len(columns))
-		for i, col := range columns {
-			width := 15
-			if len(col) > width {
-				width = len(col) + 2
-			}
-
-			tableCols[i] = table.Column{
-				Title: col,
-				Width: width,
-			}
-		}
-
-		// convert rows to bubbles
-		tableRows := make([]table.Row, len(rows))
-		for i, row := range rows {
-			tableRows[i] = row
-		}
-
-		m.queryTable.SetColumns(tableCols)
-		m.queryTable.SetRows(tableRows)
+		m.queryColumns = columns
+		m.queryLayout = newLayout(columns, rows, m.tableWidth(), 0)
+		m.queryCols = m.queryLayout.apply(&m.queryTable, columns, rows, m.tableWidth())
+		m.queryTable.GotoTop()
+		m.queryTop = 0
+	}
+}
 
+// focuses the query result column left (-1) or right (1) of the current one
+func (m *model) moveQueryFocus(d int) {
+	if m.queryLayout == nil {
+		return
 	}
+	m.queryLayout.moveFocus(d)
+	m.queryCols = m.queryLayout.apply(&m.queryTable, m.queryColumns, m.queryResult, m.tableWidth())
 }
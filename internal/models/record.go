@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// one row of the Data tab shown vertically
+type recordView struct {
+	index    int   // row in m.rows
+	field    int   // selected column
+	starts   []int // first line of each field in the viewport
+	viewport viewport.Model
+}
+
+var (
+	recordNameStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+	recordTypeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	recordSelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57"))
+)
+
+func (m *model) openRecord(index int) {
+	m.openTab(recordTab, "Record")
+	m.record = recordView{
+		index:    index,
+		viewport: viewport.New(m.width-4, max(m.dataTable.Height()-1, 3)),
+	}
+	m.renderRecord()
+}
+
+func (m *model) updateRecord(msg tea.KeyMsg) tea.Cmd {
+	r := &m.record
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.closeTab(dataTab)
+	case key.Matches(msg, keys.Left):
+		m.moveTab(-1)
+	case key.Matches(msg, keys.Right):
+		m.moveTab(1)
+	case key.Matches(msg, keys.Tab):
+		m.activeTab = m.nextTab()
+	case key.Matches(msg, keys.Up):
+		r.field = max(r.field-1, 0)
+		m.renderRecord()
+	case key.Matches(msg, keys.Down):
+		r.field = min(r.field+1, len(m.columns)-1)
+		m.renderRecord()
+	case key.Matches(msg, keys.NextRow):
+		m.showRecord(r.index + 1)
+	case key.Matches(msg, keys.PrevRow):
+		m.showRecord(r.index - 1)
+	case key.Matches(msg, keys.Copy):
+		return m.copyField()
+	default:
+		var cmd tea.Cmd
+		r.viewport, cmd = r.viewport.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+// moves to another row of the page, keeping the Data tab cursor in sync
+func (m *model) showRecord(index int) {
+	if index < 0 || index >= len(m.rows) {
+		return
+	}
+	m.record.index = index
+	m.dataTable.SetCursor(index)
+	m.dataTop = scrollTop(m.dataTop, index, m.dataTable.Height())
+	m.renderRecord()
+}
+
+func (m *model) copyField() tea.Cmd {
+	r := m.record
+	col := m.columns[r.field]
+	val := m.rows[r.index][r.field]
+
+	text := database.EditValue(val)
+	if b, ok := val.([]byte); ok {
+		text = fmt.Sprintf("%x", b)
+	}
+
+	return copyCmd(col.Name, text)
+}
+
+// puts text on the clipboard, what names it in the status line
+func copyCmd(what, text string) tea.Cmd {
+	if err := clipboard.WriteAll(text); err != nil {
+		// no clipboard utility (ex. over ssh), ask the terminal with OSC 52
+		if _, err := osc52.New(text).WriteTo(os.Stderr); err != nil {
+			return errCmd(fmt.Errorf("Failed to copy to clipboard: %w", err))
+		}
+		return statusCmd("Copied %s (%d chars) through the terminal", what, len(text))
+	}
+	return statusCmd("Copied %s (%d chars)", what, len(text))
+}
+
+// lays out the fields and scrolls the selected one into view
+func (m *model) renderRecord() {
+	r := &m.record
+	row := m.rows[r.index]
+	width := max(r.viewport.Width-4, 10)
+	valueStyle := lipgloss.NewStyle().Width(width).PaddingLeft(2)
+
+	var lines []string
+	r.starts = make([]int, len(m.columns))
+	for i, col := range m.columns {
+		r.starts[i] = len(lines)
+
+		name := col.Name
+		if i == r.field {
+			name = recordSelectedStyle.Render(name)
+		} else {
+			name = recordNameStyle.Render(name)
+		}
+		lines = append(lines, name+" "+recordTypeStyle.Render(col.Type))
+
+		value := valueStyle.Render(database.FormatValue(row[i]))
+		lines = append(lines, strings.Split(value, "\n")...)
+		lines = append(lines, "")
+	}
+	r.viewport.SetContent(strings.Join(lines, "\n"))
+
+	// keep the whole selected field visible when it fits
+	start := r.starts[r.field]
+	end := len(lines) - 1
+	if r.field+1 < len(r.starts) {
+		end = r.starts[r.field+1] - 1
+	}
+	if start < r.viewport.YOffset {
+		r.viewport.SetYOffset(start)
+	} else if end >= r.viewport.YOffset+r.viewport.Height {
+		r.viewport.SetYOffset(min(start, end-r.viewport.Height+1))
+	}
+}
+
+func (m model) recordView() string {
+	r := m.record
+	info := fmt.Sprintf("%s • row %d of %d", m.name, r.index+1, len(m.rows))
+
+	hint := recordTypeStyle.Render(fmt.Sprintf("%s %s • %s/%s prev/next row • %d%%",
+		keys.Copy.Help().Key, keys.Copy.Help().Desc,
+		keys.PrevRow.Help().Key, keys.NextRow.Help().Key,
+		int(r.viewport.ScrollPercent()*100)))
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.appBoundaryView(info),
+		r.viewport.View(),
+		hint,
+	)
+}
@@ -0,0 +1,292 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// values bound to the schema diff form
+type schemaDiffDraft struct {
+	from string
+	to   string
+}
+
+// schemas of two open databases compared, replaces the list and table views
+type schemaDiffPanel struct {
+	open     bool
+	form     *huh.Form        // picks the databases to compare
+	draft    *schemaDiffDraft // bound to form
+	diff     *database.SchemaDiff
+	selected int  // index in diff.Objects
+	script   bool // shows the migration script instead of the objects
+	viewport viewport.Model
+	width    int
+	height   int
+}
+
+var diffChangedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+// width of the object list left of the definitions
+const schemaDiffListWidth = 32
+
+func newSchemaDiffPanel() schemaDiffPanel {
+	return schemaDiffPanel{viewport: viewport.New(0, 0)}
+}
+
+func (p *schemaDiffPanel) setSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.viewport.Height = max(height-7, 3)
+	if p.script {
+		p.viewport.Width = max(width-4, 10)
+	} else {
+		p.viewport.Width = max(width-4-schemaDiffListWidth-2, 10)
+	}
+	p.render()
+}
+
+// opens the form picking the two databases, main and the first attached one
+// selected
+func (p *schemaDiffPanel) start(schemas []database.Schema) tea.Cmd {
+	if len(schemas) < 2 {
+		return statusCmd("Attach another database with %s to compare schemas with it", keys.Attach.Help().Key)
+	}
+
+	var options []huh.Option[string]
+	for _, s := range schemas {
+		label := s.Name
+		if s.File != "" {
+			label += " (" + filepath.Base(s.File) + ")"
+		}
+		options = append(options, huh.NewOption(label, s.Name))
+	}
+
+	draft := &schemaDiffDraft{from: schemas[0].Name, to: schemas[1].Name}
+	*p = schemaDiffPanel{open: true, draft: draft, viewport: p.viewport, width: p.width, height: p.height}
+	p.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("From").
+				Description("the schema the migration script starts from").
+				Options(options...).
+				Value(&draft.from),
+			huh.NewSelect[string]().
+				Title("To").
+				Description("the schema it ends with").
+				Options(options...).
+				Validate(func(s string) error {
+					if s == draft.from {
+						return fmt.Errorf("Pick another database than %s", s)
+					}
+					return nil
+				}).
+				Value(&draft.to),
+		),
+	).WithWidth(max(p.width-6, 30)).WithShowHelp(false)
+	return p.form.Init()
+}
+
+func (p *schemaDiffPanel) close() {
+	*p = schemaDiffPanel{viewport: p.viewport, width: p.width, height: p.height}
+}
+
+func (p *schemaDiffPanel) setDiff(diff *database.SchemaDiff) {
+	p.diff = diff
+	p.selected = 0
+	p.viewport.GotoTop()
+	p.setSize(p.width, p.height)
+}
+
+// passes msg to the form until it is submitted, then moves through the objects
+func (p *schemaDiffPanel) update(msg tea.Msg, store *database.Manager) tea.Cmd {
+	if p.form != nil {
+		form, cmd := p.form.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			p.form = f
+		}
+
+		switch p.form.State {
+		case huh.StateCompleted:
+			p.form = nil
+			return schemaDiffCmd(store, p.draft.from, p.draft.to)
+		case huh.StateAborted:
+			p.close()
+			return nil
+		}
+		return cmd
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || p.diff == nil {
+		return nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, keys.DiffSQL):
+		p.script = !p.script
+		p.viewport.GotoTop()
+		p.setSize(p.width, p.height)
+	case key.Matches(keyMsg, keys.Copy):
+		return copyCmd("migration script", p.diff.MigrationScript())
+	case key.Matches(keyMsg, keys.Swap):
+		return schemaDiffCmd(store, p.diff.Right, p.diff.Left)
+	case !p.script && key.Matches(keyMsg, keys.Up):
+		p.selected = max(p.selected-1, 0)
+		p.viewport.GotoTop()
+		p.render()
+	case !p.script && key.Matches(keyMsg, keys.Down):
+		p.selected = min(p.selected+1, max(len(p.diff.Objects)-1, 0))
+		p.viewport.GotoTop()
+		p.render()
+	default:
+		var cmd tea.Cmd
+		p.viewport, cmd = p.viewport.Update(keyMsg)
+		return cmd
+	}
+	return nil
+}
+
+// fills the viewport with the script or both definitions of the selected object
+func (p *schemaDiffPanel) render() {
+	if p.diff == nil {
+		return
+	}
+	width := p.viewport.Width
+
+	if p.script {
+		lines := strings.Split(highlightSQL(p.diff.MigrationScript()), "\n")
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, width, "…")
+		}
+		p.viewport.SetContent(strings.Join(lines, "\n"))
+		return
+	}
+
+	if len(p.diff.Objects) == 0 {
+		p.viewport.SetContent("The schemas are the same")
+		return
+	}
+
+	o := p.diff.Objects[p.selected]
+	half := (width - 3) / 2
+	pane := func(title string, obj *database.SchemaObject, style lipgloss.Style) string {
+		sql := dashboardDimStyle.Render("(none)")
+		if obj != nil {
+			sql = highlightSQL(obj.SQL)
+		}
+		return lipgloss.NewStyle().Width(half).Render(
+			style.Render(title) + "\n" + ansi.Wrap(sql, half, " ,"))
+	}
+	left, right := pane(p.diff.Left, o.Left, diffLeftStyle), pane(p.diff.Right, o.Right, diffRightStyle)
+	rule := strings.Repeat(" │ \n", max(lipgloss.Height(left), lipgloss.Height(right)))
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, left, strings.TrimSuffix(rule, "\n"), right)
+
+	lines := strings.Split(panes, "\n")
+	if len(o.Columns) > 0 {
+		lines = append(lines, "", diffHeaderStyle.Render("Columns"))
+		for _, c := range o.Columns {
+			switch c.Change {
+			case database.SchemaAdded:
+				lines = append(lines, diffRightStyle.Render("+ "+c.Right))
+			case database.SchemaRemoved:
+				lines = append(lines, diffLeftStyle.Render("- "+c.Left))
+			default:
+				lines = append(lines, diffLeftStyle.Render("< "+c.Left), diffRightStyle.Render("> "+c.Right))
+			}
+		}
+	}
+	if o.Rebuild {
+		lines = append(lines, "", recordTypeStyle.Render(
+			"ALTER TABLE cannot make this change, the script copies the table into a new one"))
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	p.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// the changed objects, one per line with a marker for the kind of change
+func (p schemaDiffPanel) listView() string {
+	var lines []string
+	if len(p.diff.Objects) == 0 {
+		lines = append(lines, dashboardDimStyle.Render("no differences"))
+	}
+	for i, o := range p.diff.Objects {
+		marker := diffChangedStyle.Render("~")
+		switch o.Change {
+		case database.SchemaAdded:
+			marker = diffRightStyle.Render("+")
+		case database.SchemaRemoved:
+			marker = diffLeftStyle.Render("-")
+		}
+		name := o.Type + " " + o.Name
+		if i == p.selected {
+			name = diffHeaderStyle.Render(name)
+			marker = "›" + marker
+		} else {
+			marker = " " + marker
+		}
+		lines = append(lines, ansi.Truncate(marker+" "+name, schemaDiffListWidth, "…"))
+	}
+
+	// the selected line stays on screen
+	height := p.viewport.Height
+	top := max(p.selected-height+1, 0)
+	lines = lines[top:min(top+height, len(lines))]
+	return lipgloss.NewStyle().Width(schemaDiffListWidth).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+func (p schemaDiffPanel) View() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+
+	var body []string
+	switch {
+	case p.form != nil:
+		body = []string{titleStyle.Render("Schema Diff"), "", p.form.View(),
+			dashboardDimStyle.Render("enter next • esc cancel")}
+	case p.diff == nil:
+		body = []string{titleStyle.Render("Schema Diff"), "", "Comparing..."}
+	default:
+		d := p.diff
+		title := fmt.Sprintf("Schema of %s (<) and %s (>)", d.Left, d.Right)
+		if p.script {
+			title = fmt.Sprintf("Migration from %s to %s", d.Left, d.Right)
+		}
+
+		counts := map[database.SchemaChange]int{}
+		for _, o := range d.Objects {
+			counts[o.Change]++
+		}
+		summary := fmt.Sprintf("%s • %s • %s • %d same",
+			diffRightStyle.Render(fmt.Sprintf("%d added", counts[database.SchemaAdded])),
+			diffLeftStyle.Render(fmt.Sprintf("%d removed", counts[database.SchemaRemoved])),
+			diffChangedStyle.Render(fmt.Sprintf("%d changed", counts[database.SchemaChanged])),
+			d.Same)
+
+		content := p.viewport.View()
+		if !p.script {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, p.listView(), "  ", content)
+		}
+
+		hint := dashboardDimStyle.Render(fmt.Sprintf("%s objects/script • %s copy script • %s swap sides • esc close • %d%%",
+			keys.DiffSQL.Help().Key, keys.Copy.Help().Key, keys.Swap.Help().Key,
+			int(p.viewport.ScrollPercent()*100)))
+		body = []string{titleStyle.Render(title), summary, "", content, hint}
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(highlightColor).
+		Width(p.width - 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, body...))
+}
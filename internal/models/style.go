@@ -46,6 +46,11 @@ var (
 	// 		Foreground(lipgloss.Color("212"))
 	formErrorHeaderText = formHeaderText.
 				Foreground(red)
+
+	// status line / help
+	errorStyle       = lipgloss.NewStyle().Foreground(red).Padding(0, 1)
+	statusStyle      = lipgloss.NewStyle().Foreground(green).Padding(0, 1)
+	disabledKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("238")).Strikethrough(true)
 )
 
 func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
@@ -20,28 +20,78 @@ const (
 	infoTab
 	queryTab
 	editTab
+	blobTab
+	jsonTab
+	recordTab
+	columnsTab
+	refsTab
+	indexTab
+	triggerTab
+	diffTab
+	generateTab
 )
 
+// tabs that are always shown, an extra tab can be opened after them
+var baseTabs = []string{"Data", "Info", "Query"}
+
 type model struct {
-	store       *database.Manager
-	focus       bool
-	tabs        []string
-	activeTab   tab
-	name        string
-	columns     []database.Column
-	selectedRow []string
-	dataTable   table.Model
-	infoTable   table.Model
-	queryInput  textinput.Model
-	queryTable  table.Model
-	queryResult [][]string
-	form        *huh.Form
-	toEdit      []string
-	confirmEdit bool
-	currentPage int
-	err         error
-	width       int
-	height      int
+	store        *database.Manager
+	focus        bool
+	tabs         []string
+	activeTab    tab
+	extraTab     tab // tab shown after the base tabs, if any
+	name         string
+	columns      []database.Column
+	rows         [][]any // raw values of the loaded page
+	selectedRow  []any
+	dataTable    table.Model
+	infoTable    table.Model
+	indexTable   table.Model
+	indexes      []database.Index
+	indexForm    *huh.Form   // create or drop index form
+	index        *indexDraft // bound to indexForm
+	triggerTable table.Model
+	triggers     []database.Trigger
+	triggerForm  *huh.Form     // trigger list, editor or drop form
+	trigger      *triggerDraft // bound to triggerForm
+	def          database.TableDefinition
+	showDDL      bool // Info tab shows the CREATE statements instead of the columns
+	ddlTop       int  // first DDL line on screen
+	queryInput   textinput.Model
+	queryTable   table.Model
+	queryResult  [][]any
+	form         *huh.Form
+	toEdit       []string
+	toggles      map[int]*bool // boolean fields, copied into toEdit on submit
+	nulls        map[int]*bool // fields set to NULL
+	formMode     formMode
+	confirmEdit  *bool // bound to the form, the model itself is copied on update
+	blob         blobView
+	json         jsonView
+	record       recordView
+	diff         diffView
+	generateForm *huh.Form                // generate rows form
+	generate     *generateDraft           // bound to generateForm
+	layouts      map[string]*columnLayout // Data tab layout by table name
+	visibleCols  []int                    // columns shown in the Data tab, by index
+	picker       *huh.Form                // column or reference picker
+	shownCols    *[]int                   // bound to the column picker
+	refs         []reference              // choices of the reference picker
+	refChoice    *int                     // bound to the reference picker
+	back         []navEntry               // Data tab positions before following keys
+	restore      *navEntry                // position to restore once loaded
+	dataTop      int                      // first Data tab row on screen
+	queryLayout  *columnLayout
+	queryCols    []int // query result columns on screen
+	queryColumns []string
+	queryTop     int
+	filterInput  textinput.Model
+	filtering    bool   // filter input has focus
+	filter       string // WHERE clause applied to the Data tab
+	currentPage  int
+	err          error
+	width        int
+	height       int
 }
 
 func newModel(m *database.Manager) model {
@@ -50,16 +100,23 @@ func newModel(m *database.Manager) model {
 	ti.Focus()
 	ti.Width = 50
 
+	fi := textinput.New()
+	fi.Prompt = "WHERE "
+	// the text is run as raw SQL, not escaped
+	fi.Placeholder = "raw SQL, ex. json_extract(data, '$.name') = 'x'"
+
 	return model{
-		store:      m,
-		focus:      false,
-		tabs:       []string{"Data", "Info", "Query"},
-		activeTab:  dataTab,
-		dataTable:  newTable(),
-		queryInput: ti,
-		queryTable: newTable(),
-		infoTable:  newTable(),
-		form:       nil,
+		store:       m,
+		filterInput: fi,
+		layouts:     make(map[string]*columnLayout),
+		focus:       false,
+		tabs:        append([]string(nil), baseTabs...),
+		activeTab:   dataTab,
+		dataTable:   newTable(),
+		queryInput:  ti,
+		queryTable:  newTable(),
+		infoTable:   newTable(),
+		form:        nil,
 	}
 }
 
@@ -68,6 +125,8 @@ func (m *model) setSize(w, h int) {
 	m.height = h
 	m.dataTable.SetWidth(w - 2)
 	m.queryInput.Width = w
+	m.applyLayout()
+	m.moveQueryFocus(0)
 }
 
 func (m model) Init() tea.Cmd { return nil }
@@ -80,31 +139,175 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch m.activeTab {
 		case dataTab:
+			if m.filtering {
+				return m, m.updateFilter(msg)
+			}
+
 			switch {
 			case key.Matches(msg, keys.Left):
-				m.activeTab = max(m.activeTab-1, dataTab)
+				m.moveTab(-1)
 			case key.Matches(msg, keys.Right):
-				m.activeTab = min(m.activeTab+1, tab(len(m.tabs)-1))
+				m.moveTab(1)
 			case key.Matches(msg, keys.Tab):
 				m.activeTab = m.nextTab()
 			case key.Matches(msg, keys.Edit):
-				return m, selectRowCmd(m.dataTable.SelectedRow())
+				if m.store.ReadOnly() {
+					return m, errCmd(database.ErrReadOnly)
+				}
+				if row := m.selectedData(); row != nil && m.form == nil {
+					return m, selectRowCmd(row)
+				}
+			case key.Matches(msg, keys.Insert):
+				if m.store.ReadOnly() {
+					return m, errCmd(database.ErrReadOnly)
+				}
+				if m.name != "" && m.form == nil {
+					m.onInsert()
+					return m, m.form.Init()
+				}
+			case key.Matches(msg, keys.Delete):
+				if m.store.ReadOnly() {
+					return m, errCmd(database.ErrReadOnly)
+				}
+				if row := m.selectedData(); row != nil && m.form == nil {
+					m.onDelete(row)
+					return m, m.form.Init()
+				}
+			case key.Matches(msg, keys.Blob):
+				if row := m.selectedData(); row != nil && m.form == nil {
+					if err := m.openBlob(row); err != nil {
+						return m, errCmd(err)
+					}
+				}
+			case key.Matches(msg, keys.Enter):
+				if m.selectedData() != nil && m.form == nil {
+					m.openRecord(m.dataTable.Cursor())
+					return m, nil
+				}
+			case key.Matches(msg, keys.JSON):
+				if row := m.selectedData(); row != nil && m.form == nil {
+					if err := m.openJSON(row); err != nil {
+						return m, errCmd(err)
+					}
+				}
+			case key.Matches(msg, keys.ColLeft):
+				if m.name != "" {
+					m.layout().moveFocus(-1)
+					m.applyLayout()
+				}
+			case key.Matches(msg, keys.ColRight):
+				if m.name != "" {
+					m.layout().moveFocus(1)
+					m.applyLayout()
+				}
+			case key.Matches(msg, keys.Widen):
+				if m.name != "" {
+					m.resizeColumn(2)
+				}
+			case key.Matches(msg, keys.Narrow):
+				if m.name != "" {
+					m.resizeColumn(-2)
+				}
+			case key.Matches(msg, keys.Freeze):
+				if m.name != "" {
+					m.toggleFreeze()
+				}
+			case key.Matches(msg, keys.Columns):
+				if m.name != "" && m.form == nil {
+					m.openColumnPicker()
+					return m, m.picker.Init()
+				}
+			case key.Matches(msg, keys.Filter):
+				if m.name != "" {
+					return m, m.editFilter(m.filter)
+				}
+			case key.Matches(msg, keys.Reset):
+				if m.filter != "" {
+					return m, loadTableDataCmd(m.store, m.name, "", 0)
+				}
+			case key.Matches(msg, keys.FollowRef):
+				if m.name != "" && m.form == nil {
+					return m, m.followRef()
+				}
+			case key.Matches(msg, keys.RefBy):
+				if row := m.selectedData(); row != nil && m.form == nil {
+					return m, referencesCmd(m.store, m.name, m.columns, row)
+				}
+			case key.Matches(msg, keys.NavBack):
+				if m.form == nil {
+					return m, m.goBack()
+				}
+			case key.Matches(msg, keys.Diff):
+				if m.name != "" && m.form == nil {
+					return m, diffTablesCmd(m.store, m.name)
+				}
+			case key.Matches(msg, keys.Generate):
+				if m.store.ReadOnly() {
+					return m, errCmd(database.ErrReadOnly)
+				}
+				if m.name != "" && m.form == nil {
+					return m, m.openGenerate()
+				}
 			}
 		case infoTab:
 			switch {
 			case key.Matches(msg, keys.Left):
-				m.activeTab = max(m.activeTab-1, dataTab)
+				m.moveTab(-1)
 			case key.Matches(msg, keys.Right):
-				m.activeTab = min(m.activeTab+1, tab(len(m.tabs)-1))
+				m.moveTab(1)
 			case key.Matches(msg, keys.Tab):
 				m.activeTab = m.nextTab()
+			case key.Matches(msg, keys.NewIndex), key.Matches(msg, keys.DropIndex):
+				if m.store.ReadOnly() {
+					return m, errCmd(database.ErrReadOnly)
+				}
+				if m.name == "" || m.form != nil {
+					return m, nil
+				}
+				if key.Matches(msg, keys.NewIndex) {
+					return m, m.openCreateIndex()
+				}
+				return m, m.openDropIndex()
+			case key.Matches(msg, keys.EditTrigger), key.Matches(msg, keys.DropTrigger):
+				if m.store.ReadOnly() {
+					return m, errCmd(database.ErrReadOnly)
+				}
+				if m.name == "" || m.form != nil {
+					return m, nil
+				}
+				if key.Matches(msg, keys.EditTrigger) {
+					return m, m.openEditTrigger()
+				}
+				return m, m.openDropTrigger()
+			case key.Matches(msg, keys.DDL):
+				m.showDDL = !m.showDDL
+				m.ddlTop = 0
+				return m, nil
+			case m.showDDL && key.Matches(msg, keys.Up):
+				m.ddlTop = max(m.ddlTop-1, 0)
+				return m, nil
+			case m.showDDL && key.Matches(msg, keys.Down):
+				m.ddlTop = min(m.ddlTop+1, max(len(m.ddlLines())-m.ddlHeight(), 0))
+				return m, nil
 			}
 		case queryTab:
+			// h/l are typed into the query
+			typed := msg.Type == tea.KeyRunes
 			switch {
-			case key.Matches(msg, keys.Left):
-				m.activeTab = max(m.activeTab-1, dataTab)
-			case key.Matches(msg, keys.Right):
-				m.activeTab = min(m.activeTab+1, tab(len(m.tabs)-1))
+			case key.Matches(msg, keys.Left) && !typed:
+				m.moveTab(-1)
+			case key.Matches(msg, keys.Right) && !typed:
+				m.moveTab(1)
+			case key.Matches(msg, keys.ColLeft) && !typed:
+				m.moveQueryFocus(-1)
+				return m, nil
+			case key.Matches(msg, keys.ColRight) && !typed:
+				m.moveQueryFocus(1)
+				return m, nil
+			case (key.Matches(msg, keys.Up) || key.Matches(msg, keys.Down)) && !typed:
+				m.queryTable, cmd = m.queryTable.Update(msg)
+				m.queryTop = scrollTop(m.queryTop, m.queryTable.Cursor(), m.queryTable.Height())
+				return m, cmd
 			case key.Matches(msg, keys.Tab):
 				m.activeTab = m.nextTab()
 			case key.Matches(msg, keys.Enter):
@@ -113,10 +316,86 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.queryInput.Reset()
 				return m, nil
 			}
+		case editTab:
+			if key.Matches(msg, keys.Back) {
+				m.closeTab(dataTab)
+				return m, nil
+			}
+		case blobTab:
+			return m, m.updateBlob(msg)
+		case jsonTab:
+			return m, m.updateJSON(msg)
+		case recordTab:
+			return m, m.updateRecord(msg)
+		case diffTab:
+			// keys go to the table picker while it is open
+			if m.diff.form == nil || key.Matches(msg, keys.Back) {
+				return m, m.updateDiff(msg)
+			}
+		case columnsTab, refsTab, generateTab:
+			if key.Matches(msg, keys.Back) {
+				m.closeTab(dataTab)
+				return m, nil
+			}
+		case indexTab, triggerTab:
+			if key.Matches(msg, keys.Back) {
+				m.closeTab(infoTab)
+				return m, nil
+			}
 		}
 
 	case tableDataLoadedMsg:
+		// paging and filtering keep the metadata of the table
+		switched := msg.tableName != m.name
+		if switched {
+			m.indexes = nil
+			m.triggers = nil
+			m.def = database.TableDefinition{}
+			m.ddlTop = 0
+			cmds = append(cmds, loadTableMetaCmd(m.store, msg.tableName))
+		}
+		m.filter = msg.filter
 		m.setDataTable(msg.tableName, msg.columns, msg.rows)
+		m.restorePosition()
+
+	case tableMetaLoadedMsg:
+		if msg.tableName == m.name {
+			m.indexes = msg.indexes
+			m.triggers = msg.triggers
+			m.def = msg.def
+			m.ddlTop = min(m.ddlTop, max(len(m.ddlLines())-m.ddlHeight(), 0))
+			m.setInfoTable()
+		}
+
+	case indexesChangedMsg:
+		if msg.tableName == m.name {
+			m.indexes = msg.indexes
+			m.setIndexTable()
+		}
+		return m, statusCmd("Ran %s", msg.statement)
+
+	case triggersChangedMsg:
+		if msg.tableName == m.name {
+			m.triggers = msg.triggers
+			m.setTriggerTable()
+		}
+		return m, statusCmd("%s", msg.status)
+
+	case diffTablesLoadedMsg:
+		if msg.tableName == m.name {
+			return m, m.openDiff(msg.tables)
+		}
+
+	case diffLoadedMsg:
+		if m.extraTab == diffTab && msg.diff.Left == m.name {
+			m.setDiff(msg.diff)
+		}
+
+	case referencesLoadedMsg:
+		return m, m.openReferences(msg.refs)
+
+	case jsonExtractedMsg:
+		m.setJSONResult(msg)
 
 	case rowSelectedMsg:
 		m.onRowSelect(msg.row)
@@ -124,11 +403,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case queryResultMsg:
 		m.setQueryResult(msg.columns, msg.rows, msg.err)
+		// the query may have altered the table or its indexes and triggers
+		if msg.err == nil && m.name != "" {
+			cmds = append(cmds, loadTableMetaCmd(m.store, m.name))
+		}
 	}
 
 	switch m.activeTab {
 	case dataTab:
 		m.dataTable, cmd = m.dataTable.Update(msg)
+		m.dataTop = scrollTop(m.dataTop, m.dataTable.Cursor(), m.dataTable.Height())
 		cmds = append(cmds, cmd)
 	case infoTab:
 		m.infoTable, cmd = m.infoTable.Update(msg)
@@ -137,6 +421,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.queryInput, cmd = m.queryInput.Update(msg)
 		cmds = append(cmds, cmd)
 	case editTab:
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, keys.Null) {
+			if err := m.toggleNull(); err != nil {
+				cmds = append(cmds, errCmd(err))
+			}
+		}
+
 		form, cmd := m.form.Update(msg)
 		if f, ok := form.(*huh.Form); ok {
 			m.form = f
@@ -145,8 +435,79 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		if m.form.State == huh.StateCompleted {
 			// m.activeTab = dataTab
-			cmds = append(cmds, editSubmitCmd(m.name, m.selectedRow[0], m.columns, m.toEdit))
-			m.onEditSuccess(dataTab)
+			if m.confirmEdit != nil && *m.confirmEdit {
+				cmds = append(cmds, m.submitCmd())
+			}
+			m.closeTab(dataTab)
+		}
+	case columnsTab:
+		form, cmd := m.picker.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			m.picker = f
+			cmds = append(cmds, cmd)
+		}
+
+		if m.picker.State == huh.StateCompleted {
+			m.applyColumnPicker()
+			m.closeTab(dataTab)
+		}
+	case indexTab:
+		form, cmd := m.indexForm.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			m.indexForm = f
+			cmds = append(cmds, cmd)
+		}
+
+		if m.indexForm.State == huh.StateCompleted {
+			if stmt := m.indexStatement(); stmt != "" {
+				cmds = append(cmds, execIndexCmd(m.store, m.name, stmt))
+			}
+			m.closeTab(infoTab)
+		}
+	case triggerTab:
+		form, cmd := m.triggerForm.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			m.triggerForm = f
+			cmds = append(cmds, cmd)
+		}
+
+		if m.triggerForm.State == huh.StateCompleted {
+			cmds = append(cmds, m.triggerDone())
+		}
+	case generateTab:
+		form, cmd := m.generateForm.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			m.generateForm = f
+			cmds = append(cmds, cmd)
+		}
+
+		if m.generateForm.State == huh.StateCompleted {
+			cmds = append(cmds, m.generateDone())
+			m.closeTab(dataTab)
+		}
+	case refsTab:
+		form, cmd := m.picker.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			m.picker = f
+			cmds = append(cmds, cmd)
+		}
+
+		if m.picker.State == huh.StateCompleted {
+			cmds = append(cmds, m.showReference())
+		}
+	case diffTab:
+		if m.diff.form == nil {
+			break
+		}
+		form, cmd := m.diff.form.Update(msg)
+		if f, ok := form.(*huh.Form); ok {
+			m.diff.form = f
+			cmds = append(cmds, cmd)
+		}
+
+		if m.diff.form.State == huh.StateCompleted {
+			m.diff.form = nil
+			cmds = append(cmds, diffCmd(m.store, m.name, *m.diff.other))
 		}
 	}
 	return m, tea.Batch(cmds...)
@@ -161,7 +522,7 @@ func (m model) View() string {
 
 	for i, tab := range m.tabs {
 		var style lipgloss.Style
-		isFirst, isActive := i == 0, i == int(m.activeTab)
+		isFirst, isActive := i == 0, i == m.tabIndex()
 		if isActive {
 			style = activeTabStyle
 		} else {
@@ -195,6 +556,24 @@ func (m model) View() string {
 		selected = m.queryView()
 	case editTab:
 		selected = m.formView()
+	case blobTab:
+		selected = m.blobView()
+	case jsonTab:
+		selected = m.jsonView()
+	case recordTab:
+		selected = m.recordView()
+	case diffTab:
+		selected = m.diffView()
+	case columnsTab:
+		selected = m.appBoundaryView("Columns of "+m.name) + "\n" + m.picker.View()
+	case refsTab:
+		selected = m.appBoundaryView("Referenced by") + "\n" + m.picker.View()
+	case indexTab:
+		selected = m.appBoundaryView("Indexes of "+m.name) + "\n" + m.indexForm.View()
+	case triggerTab:
+		selected = m.appBoundaryView("Triggers of "+m.name) + "\n" + m.triggerForm.View()
+	case generateTab:
+		selected = m.appBoundaryView("Generate rows in "+m.name) + "\n" + m.generateForm.View()
 	}
 
 	doc.WriteString(windowStyle.
@@ -216,3 +595,77 @@ func (m model) nextTab() tab {
 		return dataTab
 	}
 }
+
+// opens an extra tab after the base tabs, replacing any open one
+func (m *model) openTab(t tab, title string) {
+	m.tabs = append(append([]string(nil), baseTabs...), title)
+	m.extraTab = t
+	m.activeTab = t
+}
+
+// closes the extra tab and anything open in it
+func (m *model) closeTab(t tab) {
+	m.tabs = append([]string(nil), baseTabs...)
+	m.extraTab = 0
+	m.activeTab = t
+	m.selectedRow = nil
+	m.toEdit = nil
+	m.toggles = nil
+	m.nulls = nil
+	m.confirmEdit = nil
+	m.form = nil
+	m.blob = blobView{}
+	m.json = jsonView{}
+	m.record = recordView{}
+	m.diff = diffView{}
+	m.picker = nil
+	m.shownCols = nil
+	m.refs = nil
+	m.refChoice = nil
+	m.indexForm = nil
+	m.index = nil
+	m.triggerForm = nil
+	m.trigger = nil
+	m.generateForm = nil
+	m.generate = nil
+}
+
+// position of the active tab in m.tabs
+func (m model) tabIndex() int {
+	if m.activeTab < editTab {
+		return int(m.activeTab)
+	}
+	return len(baseTabs)
+}
+
+// moves to the tab left (-1) or right (1) of the active one
+func (m *model) moveTab(d int) {
+	i := min(max(m.tabIndex()+d, 0), len(m.tabs)-1)
+	if i < len(baseTabs) {
+		m.activeTab = tab(i)
+	} else {
+		m.activeTab = m.extraTab
+	}
+}
+
+// true while text is being typed, so single letter keys are not global shortcuts
+func (m model) capturing() bool {
+	switch m.activeTab {
+	case queryTab, editTab, columnsTab, refsTab, indexTab, triggerTab, generateTab:
+		return true
+	case dataTab:
+		return m.filtering
+	case blobTab:
+		return m.blob.prompt != noPrompt
+	case jsonTab:
+		return m.json.prompting
+	case diffTab:
+		return m.diff.form != nil
+	}
+	return false
+}
+
+// true if esc closes something inside the model instead of going back to the list
+func (m model) handlesBack() bool {
+	return m.activeTab == m.extraTab && m.extraTab != dataTab || m.activeTab == dataTab && m.filtering
+}
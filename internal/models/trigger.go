@@ -0,0 +1,170 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"dbtui/internal/database"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// values bound to the trigger forms
+type triggerDraft struct {
+	pick     string // trigger picked to edit, empty for a new one
+	editing  bool   // the editor is open, else the trigger list
+	original string // trigger replaced by the edited SQL, empty for a new one
+	sql      string
+	drop     string // trigger to drop
+	confirm  bool
+}
+
+func (m *model) setTriggerTable() {
+	m.triggerTable = newTable()
+	m.triggerTable.SetColumns([]table.Column{
+		{Title: "Name", Width: 24},
+		{Title: "Timing", Width: 10},
+		{Title: "Event", Width: 20},
+		{Title: "Body", Width: 40},
+	})
+
+	rows := make([]table.Row, len(m.triggers))
+	for i, t := range m.triggers {
+		rows[i] = table.Row{t.Name, t.Timing, t.Event, strings.Join(strings.Fields(t.Body()), " ")}
+	}
+	m.triggerTable.SetRows(rows)
+	m.triggerTable.SetHeight(min(max(len(rows), 1)+2, 6))
+	m.triggerTable.Blur()
+}
+
+// opens a list of the table's triggers to pick one to edit, or the editor
+// straight away when there are none
+func (m *model) openEditTrigger() tea.Cmd {
+	if len(m.triggers) == 0 {
+		return m.openTriggerEditor("")
+	}
+
+	options := []huh.Option[string]{huh.NewOption("New trigger", "")}
+	for _, t := range m.triggers {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%s %s)", t.Name, t.Timing, t.Event), t.Name))
+	}
+
+	draft := &triggerDraft{}
+	m.openTab(triggerTab, "Trigger")
+	m.trigger = draft
+	m.triggerForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Edit trigger").
+				Options(options...).
+				Value(&draft.pick),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.triggerForm.Init()
+}
+
+// opens the SQL of a trigger in the editor, a template for a new one if name is empty
+func (m *model) openTriggerEditor(name string) tea.Cmd {
+	draft := &triggerDraft{editing: true, original: name}
+	for _, t := range m.triggers {
+		if t.Name == name {
+			draft.sql = t.SQL
+		}
+	}
+	if draft.sql == "" {
+		draft.original = ""
+		draft.sql = m.store.TriggerTemplate(m.name)
+	}
+
+	title := "New trigger"
+	if draft.original != "" {
+		title = "Replace " + draft.original
+	}
+
+	store, tableName := m.store, m.name
+	m.openTab(triggerTab, "Trigger")
+	m.trigger = draft
+	m.triggerForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewText().
+				Title(title).
+				Description("alt+enter/ctrl+j new line • ctrl+e open in $EDITOR • enter next").
+				Lines(min(max(m.height-16, 6), 16)).
+				CharLimit(0).
+				ShowLineNumbers(true).
+				EditorExtension("sql").
+				Validate(func(s string) error {
+					if !database.IsCreateTrigger(s) {
+						return fmt.Errorf("Expected a CREATE TRIGGER statement")
+					}
+					return nil
+				}).
+				Value(&draft.sql),
+		),
+		huh.NewGroup(
+			huh.NewNote().
+				Title("SQL").
+				DescriptionFunc(func() string {
+					return noteText(strings.Join(store.SaveTriggerSQL(tableName, draft.original, draft.sql), ";\n\n"))
+				}, draft),
+			huh.NewConfirm().
+				Title("Save trigger?").
+				Description("a replaced trigger is dropped and created in one transaction").
+				Value(&draft.confirm),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.triggerForm.Init()
+}
+
+// opens a select of the triggers to drop one
+func (m *model) openDropTrigger() tea.Cmd {
+	if len(m.triggers) == 0 {
+		return statusCmd("%s has no triggers", m.name)
+	}
+
+	options := make([]huh.Option[string], len(m.triggers))
+	for i, t := range m.triggers {
+		options[i] = huh.NewOption(fmt.Sprintf("%s (%s %s)", t.Name, t.Timing, t.Event), t.Name)
+	}
+
+	draft := &triggerDraft{}
+	store, tableName := m.store, m.name
+	m.openTab(triggerTab, "Trigger")
+	m.trigger = draft
+	m.triggerForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Drop trigger").
+				Options(options...).
+				Value(&draft.drop),
+			huh.NewNote().
+				Title("SQL").
+				DescriptionFunc(func() string {
+					return noteText(store.DropTriggerSQL(tableName, draft.drop))
+				}, draft),
+			huh.NewConfirm().
+				Title("Drop trigger?").
+				Value(&draft.confirm),
+		),
+	).WithWidth(max(m.width-8, 40)).WithShowHelp(false)
+	return m.triggerForm.Init()
+}
+
+// handles a completed trigger form, the picked trigger opens in the editor
+func (m *model) triggerDone() tea.Cmd {
+	d := m.trigger
+	if d.drop == "" && !d.editing {
+		return m.openTriggerEditor(d.pick)
+	}
+
+	m.closeTab(infoTab)
+	switch {
+	case !d.confirm:
+		return nil
+	case d.drop != "":
+		return dropTriggerCmd(m.store, m.name, d.drop)
+	}
+	return saveTriggerCmd(m.store, m.name, d.original, d.sql)
+}
@@ -5,18 +5,110 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
 )
 
 type Args struct {
-	Help   bool
-	Seed   bool
-	DBPath string
+	Command    string // subcommand, ex. schema-diff, empty to start the browser
+	Help       bool
+	Seed       bool
+	SeedPath   string // SQL script or fixtures given as -seed=PATH
+	SeedAppend bool   // keep existing tables and rows when seeding
+	ReadOnly   bool
+	Create     bool
+	Tx         bool
+	DBPath     string
+	Attach     []string  // further databases, attached under their file name
+	Labels     labelFlag // label column by table for foreign key pickers
+	Diagram    string    // print the schema as "dot" or "mermaid" and exit
+	Migrations string    // directory of the migration files
+	SeedRows   int       // rows generated for each table
+	SeedValue  int64     // seed of the generated rows, 0 for a random one
+	SeedTables []string  // existing tables filled with generated rows
+
+	// seeding without a terminal
+	SeedMissing bool // only create and fill the demo tables that do not exist
+	SeedDryRun  bool // print what seeding would do and exit
+	Yes         bool // seed without asking, -yes or -force
+}
+
+// -seed alone seeds the demo tables, -seed=PATH a SQL script or fixtures
+type seedFlag struct {
+	set  bool
+	path string
+}
+
+func (s *seedFlag) IsBoolFlag() bool { return true }
+
+func (s *seedFlag) String() string { return s.path }
+
+func (s *seedFlag) Set(v string) error {
+	switch v {
+	case "true":
+		s.set = true
+	case "false":
+		s.set = false
+	default:
+		s.set = true
+		s.path = v
+	}
+	return nil
+}
+
+// repeatable -label table=column
+type labelFlag map[string]string
+
+func (l labelFlag) String() string {
+	var pairs []string
+	for table, col := range l {
+		pairs = append(pairs, table+"="+col)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l labelFlag) Set(s string) error {
+	table, col, ok := strings.Cut(s, "=")
+	if !ok || table == "" || col == "" {
+		return fmt.Errorf("expected table=column, got %q", s)
+	}
+	l[table] = col
+	return nil
 }
 
 func ParseArgs() *Args {
-	args := Args{}
+	args := Args{Labels: labelFlag{}}
+
+	// dbtui schema-diff FROM TO prints the migration from one schema to the other
+	if len(os.Args) > 1 && os.Args[1] == "schema-diff" {
+		if len(os.Args) != 4 {
+			usage("schema-diff needs the FROM and TO database paths")
+		}
+		args.Command = os.Args[1]
+		args.ReadOnly = true
+		args.DBPath = os.Args[2]
+		args.Attach = os.Args[3:]
+		return &args
+	}
+
 	flag.BoolVar(&args.Help, "h", false, "Displays this help message")
-	flag.BoolVar(&args.Seed, "seed", false, "Seeds database with test data")
+	var seed seedFlag
+	flag.Var(&seed, "seed", "Seeds database with test data, or from -seed=PATH of a SQL script or JSON/YAML fixtures")
+	flag.BoolVar(&args.SeedAppend, "seed-append", false, "Keeps existing tables and rows when seeding")
+	flag.BoolVar(&args.SeedMissing, "seed-missing", false, "Only creates and fills the demo tables that do not exist yet")
+	flag.BoolVar(&args.SeedDryRun, "seed-dry-run", false, "Prints what seeding would drop, empty and fill, and exits")
+	flag.BoolVar(&args.Yes, "yes", false, "Seeds without asking before dropping or emptying tables")
+	flag.BoolVar(&args.Yes, "force", false, "Same as -yes")
+	flag.BoolVar(&args.ReadOnly, "readonly", false, "Opens the database in read-only mode")
+	flag.BoolVar(&args.Create, "create", false, "Creates the database file if it does not exist")
+	flag.BoolVar(&args.Tx, "tx", false, "Holds all changes in a transaction until committed")
+	flag.StringVar(&args.Diagram, "er", "", "Prints the schema diagram as dot or mermaid and exits")
+	flag.StringVar(&args.Migrations, "migrations", "", "Directory of NNNN_name.up.sql/.down.sql migration files")
+	flag.IntVar(&args.SeedRows, "seed-rows", 10, "Rows generated for each table by -seed and -seed-tables")
+	flag.Int64Var(&args.SeedValue, "seed-value", 0, "Seed of the generated rows, the same seed gives the same rows")
+	seedTables := flag.String("seed-tables", "", "Comma separated existing tables to fill with generated rows")
+	flag.Var(args.Labels, "label", "Column shown for rows of a table in foreign key pickers, as table=column")
 	flag.Parse()
 
 	if args.Help {
@@ -24,24 +116,103 @@ func ParseArgs() *Args {
 	}
 
 	remaining := flag.Args()
-	if len(remaining) != 1 {
+	if len(remaining) == 0 {
 		usage("DB PATH is missing")
 	}
 
+	args.Seed = seed.set
+	args.SeedPath = seed.path
+	if args.SeedAppend && !args.Seed {
+		usage("-seed-append needs -seed")
+	}
+	if args.SeedMissing && (!args.Seed || args.SeedPath != "" || args.SeedAppend) {
+		usage("-seed-missing needs -seed without a PATH or -seed-append")
+	}
+
+	if *seedTables != "" {
+		for _, t := range strings.Split(*seedTables, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				args.SeedTables = append(args.SeedTables, t)
+			}
+		}
+	}
+
+	if args.ReadOnly && (args.Seed || len(args.SeedTables) > 0) {
+		usage("-seed cannot be used with -readonly")
+	}
+
+	if args.SeedDryRun && !args.Seed && len(args.SeedTables) == 0 {
+		usage("-seed-dry-run needs -seed or -seed-tables")
+	}
+
+	if args.SeedRows <= 0 {
+		usage("-seed-rows must be positive")
+	}
+
+	if args.Diagram != "" && args.Diagram != "dot" && args.Diagram != "mermaid" {
+		usage("-er must be dot or mermaid")
+	}
+
+	if args.ReadOnly && args.Tx {
+		usage("-tx cannot be used with -readonly")
+	}
+
 	args.DBPath = remaining[0]
+	args.Attach = remaining[1:]
 
 	return &args
 }
 
+// false when input is piped or redirected, ex. in scripts and CI
+func StdinIsTerminal() bool {
+	return term.IsTerminal(os.Stdin.Fd())
+}
+
 func usage(msg string) {
 	if msg != "" {
 		log.Println(msg)
 	}
 
-	fmt.Printf(`Usage: dbtui [OPTIONS] <DB PATH>
+	fmt.Printf(`Usage: dbtui [OPTIONS] <DB PATH> [DB PATH...]
+	further databases are attached and named after their file, ex. prod.db as prod
+       dbtui schema-diff <FROM DB PATH> <TO DB PATH>
+	prints the SQL script migrating the schema of FROM to the schema of TO
 Options:
-	-h     Displays this help message
-	-seed  Inserts dummy data into the database
+	-h         Displays this help message
+	-seed      Creates demo tables (dropped first) filled with generated data
+	-seed=PATH Runs a .sql script in a transaction, or inserts the rows of a
+	           .json/.yaml/.yml fixture file or a directory of them, emptying
+	           their tables first (rows are upserted on their primary key)
+	-seed-append
+	           Keeps existing tables and rows: the demo tables are only created
+	           if missing and fixture rows are added or updated
+	-seed-missing
+	           Only creates and fills the demo tables that do not exist yet,
+	           needs -seed without a PATH
+	-seed-dry-run
+	           Prints what seeding would drop, empty, create and fill, and exits
+	           without changing anything (with -seed or -seed-tables)
+	-yes, -force
+	           Seeds without asking before dropping or emptying tables (the file
+	           is still backed up). Needed when stdin is not a terminal, there
+	           seeding that would lose tables or rows fails without it
+	-seed-rows
+	           n, rows generated for each table (10 if not set)
+	-seed-value
+	           n, seed of the generated rows, the same seed gives the same rows
+	           (random and printed if not set)
+	-seed-tables
+	           table,table, fills existing tables with generated rows instead,
+	           empty tables they reference are filled too
+	-readonly  Opens the database read-only and disables editing
+	-create    Creates the database file if it does not exist
+	-tx        Transaction mode, changes are held until committed (ctrl+s)
+	-er        dot|mermaid, prints the schema diagram (Graphviz or Mermaid) and exits
+	-label     table=column, column shown for rows of table in foreign key
+	           pickers (repeatable, guessed from name/username/title if not set)
+	-migrations
+	           dir, NNNN_name.up.sql/.down.sql files shown and applied with M
+	           (the migrations directory next to the database if not set)
 `)
 	os.Exit(1)
 }
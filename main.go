@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"dbtui/internal/database"
@@ -12,21 +13,83 @@ import (
 func main() {
 	args := utils.ParseArgs()
 
-	manager, err := database.NewManager(args.DBPath)
+	manager, err := database.NewManager(args.DBPath, database.Options{
+		ReadOnly:   args.ReadOnly,
+		Create:     args.Create,
+		Labels:     args.Labels,
+		Migrations: args.Migrations,
+	})
 	if err != nil {
 		log.Fatalln("Error opening database:", err)
 	}
 
 	defer manager.Close()
 
+	interactive := utils.StdinIsTerminal()
+	seed := database.SeedOptions{
+		Path:        args.SeedPath,
+		Append:      args.SeedAppend,
+		Missing:     args.SeedMissing,
+		Generate:    database.GenerateOptions{Rows: args.SeedRows, Seed: args.SeedValue},
+		DryRun:      args.SeedDryRun,
+		Yes:         args.Yes,
+		Interactive: interactive,
+	}
 	if args.Seed {
-		err = manager.CheckEmpty()
-		if err != nil {
+		if err := manager.CheckEmpty(seed); err != nil {
 			manager.Close()
 			log.Fatalln("Error checking database:", err)
 		}
 	}
 
+	if len(args.SeedTables) > 0 {
+		if err := manager.SeedTables(args.SeedTables, seed); err != nil {
+			manager.Close()
+			log.Fatalln("Error generating rows:", err)
+		}
+	}
+
+	// the browser needs a terminal, seeding from a script stops here
+	if args.SeedDryRun || ((args.Seed || len(args.SeedTables) > 0) && !interactive) {
+		return
+	}
+
+	var attached []string
+	for _, path := range args.Attach {
+		schema, err := manager.Attach(path, "")
+		if err != nil {
+			manager.Close()
+			log.Fatalln("Error attaching database:", err)
+		}
+		attached = append(attached, schema)
+	}
+
+	if args.Command == "schema-diff" {
+		diff, err := manager.DiffSchemas("main", attached[0])
+		if err != nil {
+			manager.Close()
+			log.Fatalln("Error comparing schemas:", err)
+		}
+		fmt.Print(diff.MigrationScript())
+		return
+	}
+
+	if args.Diagram != "" {
+		tables, err := manager.GetSchema()
+		if err != nil {
+			manager.Close()
+			log.Fatalln("Error reading schema:", err)
+		}
+		if args.Diagram == "dot" {
+			fmt.Print(database.SchemaDOT(tables))
+		} else {
+			fmt.Print(database.SchemaMermaid(tables))
+		}
+		return
+	}
+
+	manager.SetTxMode(args.Tx)
+
 	app := models.NewApp(manager)
 
 	p := tea.NewProgram(